@@ -4,6 +4,8 @@ go 1.24.3
 
 require (
 	github.com/google/uuid v1.6.0
+	golang.org/x/term v0.32.0
+	gopkg.in/yaml.v3 v3.0.1
 	gorm.io/gorm v1.30.0
 )
 
@@ -59,10 +61,10 @@ require (
 	golang.org/x/tools v0.33.0 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 require (
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/redis/go-redis/v9 v9.10.0
@@ -0,0 +1,538 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gofmtcomment/replacer"
+
+	"golang.org/x/term"
+)
+
+// Example usage with a sample Go file
+func createSampleFile() {
+	sampleCode := `package main
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// HTTP Status Codes
+const (
+	StatusSuccess      = 200
+	StatusCreated      = 201
+	StatusBadRequest   = 400
+	StatusUnauthorized = 401
+	StatusNotFound     = 404
+	StatusServerError  = 500
+)
+
+// Response Messages
+const (
+	MessageSuccess    = "Operation completed successfully"
+	MessageCreated    = "Resource created successfully"
+	MessageBadRequest = "Invalid request parameters"
+	MessageNotFound   = "Resource not found"
+)
+
+// API Version
+var APIVersion = "v1"
+
+type User struct {
+	ID   int    ` + "`" + `json:"id"` + "`" + `
+	Name string ` + "`" + `json:"name"` + "`" + `
+}
+
+// Before processing (with variables):
+// @Summary Get all users
+// @Description Retrieve all users from the system
+// @Tags users
+// @Accept json
+// @Produce json
+// @Success {{StatusSuccess}} {object} User "{{MessageSuccess}}"
+// @Failure {{StatusBadRequest}} {object} ErrorResponse "{{MessageBadRequest}}"
+// @Failure {{StatusNotFound}} {object} ErrorResponse "{{MessageNotFound}}"
+// @Failure {{StatusServerError}} {object} ErrorResponse "Server error"
+// @Router /api/{{APIVersion}}/users [get]
+func GetUsers(c *gin.Context) {
+	// Implementation
+	c.JSON(StatusSuccess, gin.H{"users": []User{}})
+}
+
+// Alternative syntax examples:
+// @Success ${StatusCreated} {object} User "${MessageCreated}"
+// @Success @VAR(StatusSuccess) {object} User "@VAR(MessageSuccess)"
+func CreateUser(c *gin.Context) {
+	c.JSON(StatusCreated, gin.H{"message": MessageCreated})
+}
+`
+
+	err := ioutil.WriteFile("sample.go", []byte(sampleCode), 0644)
+	if err != nil {
+		fail(fmt.Errorf("failed to create sample file: %v", err))
+	}
+	fmt.Fprintln(os.Stderr, "Created sample.go")
+}
+
+// stringList is a flag value collecting repeated string flags
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe, file or
+// other device such as /dev/null
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// readManifest returns the paths listed in the file at path, one per line,
+// skipping blank lines
+func readManifest(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file list: %v", err)
+	}
+	var paths []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths, nil
+}
+
+// printConstants prints the extracted constant table, one constant per row
+func printConstants(constants []replacer.Constant) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVALUE\tTYPE\tDEFINED AT")
+	for _, c := range constants {
+		value := fmt.Sprintf("%v", c.Value)
+		switch v := c.Value.(type) {
+		case string:
+			value = strconv.Quote(v)
+		case rune:
+			value = strconv.QuoteRune(v)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Name, value, c.Type, c.Location)
+	}
+	w.Flush()
+}
+
+// printResults prints a line for every file that was changed
+func printResults(results []*replacer.Result) {
+	for _, res := range results {
+		if res.LinesChanged > 0 {
+			fmt.Fprintf(os.Stderr, "Updated %s: %d line(s), %d substitution(s)\n", res.File, res.LinesChanged, len(res.Substitutions))
+		}
+	}
+}
+
+// printStats prints the number of placeholders each variable resolved as
+// an aligned table on stderr
+func printStats(usage []replacer.VariableUsage) {
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VARIABLE\tUSES")
+	for _, u := range usage {
+		fmt.Fprintf(w, "%s\t%d\n", u.Name, u.Count)
+	}
+	w.Flush()
+}
+
+// Exit codes, documented for scripting
+const (
+	exitUsage      = 1 // invalid flags or arguments
+	exitError      = 2 // a file couldn't be read, parsed or written
+	exitUnresolved = 3 // --strict or --max-unresolved failed
+	exitCheck      = 4 // --check found files that need substitution
+)
+
+// fail prints err and exits with the code matching it
+func fail(err error) {
+	code := exitError
+	if errors.Is(err, replacer.ErrUnresolved) || errors.Is(err, replacer.ErrConflict) || errors.Is(err, replacer.ErrMalformed) {
+		code = exitUnresolved
+	}
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	os.Exit(code)
+}
+
+// usageError prints an error about the command line and exits
+func usageError(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
+	os.Exit(exitUsage)
+}
+
+// printUsage prints the command-line help
+func printUsage() {
+	fmt.Println("Swagger Variable Replacer")
+	fmt.Println("Usage:")
+	fmt.Println("  go run gofmtcomment [flags] <file.go>   - Process single file")
+	fmt.Println("  go run gofmtcomment [flags] <directory> - Process directory")
+	fmt.Println("  go run gofmtcomment [flags] <path>...   - Process files, directories and globs together")
+	fmt.Println("  go run gofmtcomment [flags] -           - Filter stdin to stdout")
+	fmt.Println("  go run gofmtcomment --sample           - Create sample file")
+	fmt.Println("  go run gofmtcomment --help             - Show this help")
+	fmt.Println("")
+	fmt.Println("Flags:")
+	flag.CommandLine.SetOutput(os.Stdout)
+	flag.PrintDefaults()
+	fmt.Println("")
+	fmt.Println("Supported variable patterns:")
+	fmt.Println("  {{VariableName}}     - Double braces")
+	fmt.Println("  ${VariableName}      - Dollar brace")
+	fmt.Println("  @VAR(VariableName)   - Function-like")
+	fmt.Println("  @VAR(VariableName, \"default\") - Function-like, with a fallback if not found")
+	fmt.Println("")
+	fmt.Println("Exit codes:")
+	fmt.Println("  0 - Success")
+	fmt.Println("  1 - Invalid flags or arguments")
+	fmt.Println("  2 - A file couldn't be read, parsed or written")
+	fmt.Println("  3 - Unresolved variables or conflicting constants with --strict, or too many with --max-unresolved")
+	fmt.Println("  4 - Files need substitution with --check")
+}
+
+// Command-line interface
+func main() {
+	sample := flag.Bool("sample", false, "Create sample file")
+	help := flag.Bool("help", false, "Show this help")
+	dryRun := flag.Bool("dry-run", false, "Print pending changes as a unified diff without writing")
+	noColor := flag.Bool("no-color", false, "Don't colorize --dry-run and --summary output, which is colored on a terminal")
+	summary := flag.Bool("summary", false, "Like --dry-run, but print only each changed line as before -> after, grouped by file, with line numbers in --verbose mode")
+	check := flag.Bool("check", false, "List files that need substitution without writing; exit 4 if any")
+	showProgress := flag.Bool("progress", false, "Show a count of the files extracted and replaced in directory mode on stderr")
+	verbose := flag.Bool("verbose", false, "Log every processed file and replaced line")
+	quiet := flag.Bool("quiet", false, "Print only warnings and errors, without progress and summary messages")
+	warningFormat := flag.String("warning-format", "prose", "Print located warnings as prose or as gcc-style file:line:col: message lines (`format`: prose or gcc)")
+	strict := flag.Bool("strict", false, "Exit non-zero if any variable can't be resolved")
+	maxUnresolved := flag.Int("max-unresolved", -1, "Exit non-zero if more than `N` variables can't be resolved (-1 for no limit)")
+	writeReadOnly := flag.Bool("write-read-only", false, "Rewrite read-only files, keeping them read-only, instead of skipping them")
+	followSymlinks := flag.Bool("follow-symlinks", false, "Walk into symlinked directories in directory mode")
+	includeTests := flag.Bool("include-tests", false, "Also extract constants from _test.go files in directory mode (they are only substituted with --process-tests)")
+	processTests := flag.Bool("process-tests", false, "Also substitute placeholders in _test.go files in directory mode")
+	includeGenerated := flag.Bool("include-generated", false, "Also process files with a \"Code generated ... DO NOT EDIT.\" header in directory mode")
+	maxDepth := flag.Int("max-depth", -1, "Descend at most `N` directory levels below each directory (0 for its files only, -1 for no limit)")
+	timeout := flag.Duration("timeout", 0, "Stop processing after `duration`, e.g. 30s, leaving unprocessed files untouched (0 for no limit)")
+	jobs := flag.Int("jobs", 1, "Number of files to process concurrently in directory mode")
+	patterns := flag.String("patterns", "braces,dollar,var", "Comma-separated built-in placeholder `styles` to substitute: braces, dollar, var")
+	filesFrom := flag.String("files-from", "", "Also process the paths listed in `file`, one per line")
+	extractRoot := flag.String("extract-root", "", "Extract constants from every Go file under `dir` too, resolving processed files from all of them")
+	scope := flag.String("scope", "file", "Resolve placeholders from the constants of the same `file` or of the whole dir; add struct-fields, as in dir,struct-fields, to substitute only in struct field comments")
+	preferLocal := flag.Bool("prefer-local", false, "Resolve placeholders from the file's own constants before other files'")
+	astComments := flag.Bool("ast-comments", false, "Locate comments by parsing each file instead of scanning lines for comment markers")
+	lintPlaceholders := flag.Bool("lint-placeholders", false, "Warn about comment text that looks like a placeholder with mistyped delimiters, such as {Name} or {{Name}, failing with --strict")
+	gofmt := flag.Bool("gofmt", false, "Format changed files with gofmt before writing them")
+	inStrings := flag.Bool("in-strings", false, "Also substitute placeholders inside string literals, which modifies code")
+	markUnresolved := flag.Bool("mark-unresolved", false, "Append a // TODO: unresolved comment listing the unresolved placeholders of each line")
+	recursiveResolve := flag.Int("recursive-resolve", 0, "Resolve placeholders inside substituted values up to `depth` levels deep (0 inserts values literally)")
+	replaceOnce := flag.Bool("replace-once", false, "Substitute only the first occurrence of each placeholder in a comment line")
+	align := flag.Bool("align", false, "Re-align comment columns separated by two or more spaces after substitution")
+	backup := flag.Bool("backup", false, "Back up each modified file to <name>.backup before writing")
+	backupSuffix := flag.String("backup-suffix", "", "With --backup, back up files to <name>`suffix` instead of <name>.backup")
+	backupDir := flag.String("backup-dir", "", "With --backup, write backups under `dir`, mirroring the paths of the files, instead of next to them")
+	watch := flag.String("watch", "", "Process `dir`, then keep re-processing Go files as they are saved")
+	stdin := flag.Bool("stdin", false, "Read source from stdin and write the result to stdout (same as passing -)")
+	stringer := flag.Bool("stringer", false, "Substitute typed constants with what their type's String method returns, when it is a switch")
+	runeCodes := flag.Bool("rune-codes", false, "Substitute rune constants as their numeric code point instead of the character")
+	floatFormat := flag.String("float-format", "", "fmt `format` for float values, e.g. %.2f (default: as written in source)")
+	indexOut := flag.String("index-out", "", "Write the constants of every Go file under the dir argument to the index `file` and exit")
+	indexIn := flag.String("index-in", "", "Load constants from an index `file` written with --index-out instead of extracting them again")
+	listConstants := flag.String("list-constants", "", "Print the constants extracted from a file or dir at `path` without modifying anything")
+	stats := flag.Bool("stats", false, "Print how many placeholders each variable resolved, including unused constants")
+	root := flag.String("root", "", "Name files in reports, diffs and --backup-dir relative to `dir` instead of as given")
+	reportPath := flag.String("report", "", "Write a JSON summary of all substitutions to `file`")
+	env := flag.Bool("env", false, "Resolve variables not defined in source from environment variables")
+	reverse := flag.String("reverse", "", "Turn values back into placeholders using a JSON `mapping` of value to placeholder")
+	override := flag.Bool("override", false, "Let the config's constant_map take precedence over constants defined in source")
+	configPath := flag.String("config", "", "Load patterns, exclusions and constants from a JSON `file`")
+	var buildTags []string
+	filterBuild := false
+	flag.Func("build-tags", "Skip files whose build constraints aren't satisfied with the comma-separated `tags` and current GOOS/GOARCH", func(value string) error {
+		filterBuild = true
+		buildTags = nil
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				buildTags = append(buildTags, tag)
+			}
+		}
+		return nil
+	})
+	var packages stringList
+	flag.Var(&packages, "package", "Resolve {{pkg.Name}} references from the package in `dir` (repeatable)")
+	resolveImports := flag.Bool("resolve-imports", false, "Resolve {{pkg.Name}} references to packages a file imports, and names of those it dot imports, such as the standard library, from source")
+	var dataFiles stringList
+	flag.Var(&dataFiles, "data", "Load constants from a flat JSON or YAML `file` of names to values (repeatable)")
+	noSource := flag.Bool("no-source", false, "Don't extract constants from the processed files, only from --data, --package and --config")
+	var excludes stringList
+	flag.Var(&excludes, "exclude", "Skip files matching a glob `pattern` in directory mode (repeatable, supports **)")
+	flag.Usage = printUsage
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(exitUsage)
+	}
+
+	switch {
+	case *sample:
+		createSampleFile()
+		return
+	case *help:
+		fmt.Println("This tool processes Go files and replaces variable references in comments.")
+		fmt.Println("It extracts constants and variables from Go files and substitutes them in comments.")
+		return
+	case flag.NArg() < 1 && *filesFrom == "" && !*stdin && *watch == "" && *listConstants == "":
+		printUsage()
+		os.Exit(exitUsage)
+	}
+
+	arg := flag.Arg(0)
+
+	rep := replacer.NewSwaggerVariableReplacer()
+	rep.SetDryRun(*dryRun || *summary)
+	rep.SetSummary(*summary)
+	rep.SetColor(!*noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout))
+	rep.SetCheck(*check)
+	rep.SetBackup(*backup)
+	rep.SetStrict(*strict)
+	rep.SetVerbose(*verbose)
+	rep.SetQuiet(*quiet)
+	if err := rep.SetWarningFormat(*warningFormat); err != nil {
+		usageError("%v", err)
+	}
+	if *showProgress {
+		rep.SetProgress(os.Stderr)
+	}
+	rep.SetAlign(*align)
+	rep.SetGofmt(*gofmt)
+	rep.SetInStrings(*inStrings)
+	rep.SetReplaceOnce(*replaceOnce)
+	rep.SetRecursiveResolve(*recursiveResolve)
+	rep.SetMarkUnresolved(*markUnresolved)
+	rep.SetRoot(*root)
+	if *inStrings {
+		fmt.Fprintln(os.Stderr, "Warning: --in-strings substitutes placeholders in string literals, modifying code and not just comments")
+	}
+	rep.SetASTComments(*astComments)
+	rep.SetLintPlaceholders(*lintPlaceholders)
+	rep.SetJobs(*jobs)
+	rep.SetMaxUnresolved(*maxUnresolved)
+	rep.SetMaxDepth(*maxDepth)
+	rep.SetIncludeGenerated(*includeGenerated)
+	rep.SetIncludeTests(*includeTests)
+	rep.SetProcessTests(*processTests)
+	rep.SetFollowSymlinks(*followSymlinks)
+	rep.SetWriteReadOnly(*writeReadOnly)
+	rep.SetOverride(*override)
+	rep.SetEnv(*env)
+	rep.SetRuneCodes(*runeCodes)
+	rep.SetStringer(*stringer)
+	if *reverse != "" {
+		mapping, err := replacer.LoadMapping(*reverse)
+		if err != nil {
+			fail(err)
+		}
+		rep.SetReverse(mapping)
+	}
+	if filterBuild {
+		rep.SetBuildTags(buildTags)
+	}
+	rep.SetPreferLocal(*preferLocal)
+	var styles []string
+	for _, style := range strings.Split(*patterns, ",") {
+		if style = strings.TrimSpace(style); style != "" {
+			styles = append(styles, style)
+		}
+	}
+	if err := rep.SetBuiltinPatterns(styles); err != nil {
+		usageError("%v", err)
+	}
+	resolve := "file"
+	for _, part := range strings.Split(*scope, ",") {
+		switch part = strings.TrimSpace(part); part {
+		case "file", "dir":
+			resolve = part
+		case "struct-fields":
+			rep.SetStructFieldComments(true)
+		default:
+			usageError("unknown scope %q (want file, dir or struct-fields)", part)
+		}
+	}
+	// Constants of the extract root are meant for every file
+	rep.SetFileScope(resolve == "file" && *extractRoot == "")
+
+	cfg := &replacer.Config{}
+	if *configPath != "" {
+		var err error
+		if cfg, err = replacer.LoadConfig(*configPath); err != nil {
+			fail(err)
+		}
+	}
+	cfg.ExcludeFiles = append(cfg.ExcludeFiles, excludes...)
+	if *floatFormat != "" {
+		cfg.FloatFormat = *floatFormat
+	}
+	if *backupSuffix != "" {
+		cfg.BackupSuffix = *backupSuffix
+	}
+	if *backupDir != "" {
+		cfg.BackupDir = *backupDir
+	}
+	if err := rep.ApplyConfig(cfg); err != nil {
+		fail(err)
+	}
+
+	for _, dir := range packages {
+		if err := rep.AddPackage(dir); err != nil {
+			fail(err)
+		}
+	}
+	for _, path := range dataFiles {
+		if err := rep.AddData(path); err != nil {
+			fail(err)
+		}
+	}
+	if *indexIn != "" {
+		index, err := replacer.LoadIndex(*indexIn)
+		if err != nil {
+			fail(err)
+		}
+		rep.UseIndex(index)
+	}
+	rep.SetNoSource(*noSource)
+	if *resolveImports {
+		rep.SetPackageResolver(replacer.ImporterResolver())
+	}
+
+	if *indexOut != "" {
+		if flag.NArg() != 1 {
+			usageError("--index-out takes exactly one directory")
+		}
+		index, err := rep.BuildIndex(arg)
+		if err != nil {
+			fail(err)
+		}
+		if err := index.Save(*indexOut); err != nil {
+			fail(err)
+		}
+		fmt.Fprintf(os.Stderr, "Indexed %d constant(s) in %s\n", len(index.Constants), *indexOut)
+		return
+	}
+
+	if *listConstants != "" {
+		info, err := os.Stat(*listConstants)
+		if err != nil {
+			fail(err)
+		}
+		if info.IsDir() {
+			err = rep.ExtractFromDir(*listConstants)
+		} else {
+			err = rep.ExtractFromFile(*listConstants)
+		}
+		if err != nil {
+			fail(err)
+		}
+		printConstants(rep.Constants())
+		return
+	}
+
+	if *watch != "" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		fmt.Fprintf(os.Stderr, "Watching directory: %s\n", *watch)
+		if err := rep.Watch(ctx, *watch); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	if *stdin || arg == "-" {
+		if err := rep.ProcessReader(os.Stdin, os.Stdout); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	// Files, directories and globs all share one constant table
+	paths := flag.Args()
+	if *filesFrom != "" {
+		listed, err := readManifest(*filesFrom)
+		if err != nil {
+			fail(err)
+		}
+		paths = append(paths, listed...)
+	}
+	if *extractRoot != "" {
+		if err := rep.ExtractFromDir(*extractRoot); err != nil {
+			fail(err)
+		}
+	}
+	if !*check && !*quiet {
+		fmt.Fprintf(os.Stderr, "Processing: %s\n", strings.Join(paths, " "))
+	}
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	err := rep.ProcessPathsContext(ctx, paths)
+
+	if *reportPath != "" {
+		if reportErr := rep.WriteReport(*reportPath); reportErr != nil {
+			fail(reportErr)
+		}
+	}
+
+	if err != nil {
+		fail(err)
+	}
+
+	switch {
+	case *check:
+		for _, res := range rep.Results() {
+			if res.LinesChanged > 0 {
+				fmt.Println(res.File)
+			}
+		}
+		if rep.Pending() > 0 {
+			os.Exit(exitCheck)
+		}
+	case *quiet:
+	case *dryRun || *summary:
+		fmt.Fprintf(os.Stderr, "Dry run: %d line(s) would be changed\n", rep.Pending())
+	default:
+		printResults(rep.Results())
+		fmt.Fprintln(os.Stderr, "Processing completed!")
+	}
+	if *stats {
+		printStats(rep.Usage())
+	}
+}
+
+// Additional features you can add:
+
+// 1. Integration with go generate
+// Add this comment to your Go files:
+// //go:generate go run swagger-gofmtcomment .
+
+// 2. Git hook integration
+// Pre-commit hook that runs the replacer:
+// #!/bin/sh
+// go run main.go .
+// git add -A
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain runs main instead of the tests when the test binary is started
+// by runMain
+func TestMain(m *testing.M) {
+	if os.Getenv("GOFMTCOMMENT_RUN_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs the command with args in dir and returns its stdout, stderr
+// and exit code
+func runMain(t *testing.T, dir string, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	return runMainInput(t, dir, "", args...)
+}
+
+// runMainInput is runMain with stdin reading from input
+func runMainInput(t *testing.T, dir, input string, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(exe, args...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Env = append(os.Environ(), "GOFMTCOMMENT_RUN_MAIN=1")
+	var out, errOut bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		code = exitErr.ExitCode()
+	case err != nil:
+		t.Fatal(err)
+	}
+	return out.String(), errOut.String(), code
+}
+
+// writeTestFile writes content to name under dir and returns its path
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readTestFile returns the content of path, failing the test on error
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestIsTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if isTerminal(w) {
+		t.Error("pipe reported as a terminal")
+	}
+
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer null.Close()
+	if isTerminal(null) {
+		t.Errorf("%s reported as a terminal", os.DevNull)
+	}
+}
+
+func TestDryRunFlag(t *testing.T) {
+	dir := t.TempDir()
+	src := "package api\n\nconst A = 1\n\n// {{A}}\n"
+	path := writeTestFile(t, dir, "a.go", src)
+	stdout, stderr, code := runMain(t, dir, "--dry-run", "a.go")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "-// {{A}}\n+// 1\n") {
+		t.Errorf("unexpected diff:\n%s", stdout)
+	}
+	if !strings.Contains(stderr, "Dry run: 1 line(s) would be changed") {
+		t.Errorf("missing count on stderr:\n%s", stderr)
+	}
+	if got := readTestFile(t, path); got != src {
+		t.Errorf("file written in dry run: %q", got)
+	}
+}
+
+func TestConfigFlagErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n")
+	writeTestFile(t, dir, "bad.json", `{"patterns": ["(unclosed"]}`)
+	for _, config := range []string{"missing.json", "bad.json"} {
+		_, stderr, code := runMain(t, dir, "--config", config, "a.go")
+		if code == 0 || !strings.Contains(stderr, "Error:") {
+			t.Errorf("--config %s: exit code %d, stderr %q", config, code, stderr)
+		}
+	}
+}
+
+func TestStrictExitCode(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\n// {{Missing}}\n")
+	_, stderr, code := runMain(t, dir, "--strict", "a.go")
+	if code != exitUnresolved || !strings.Contains(stderr, "Missing (") {
+		t.Errorf("exit code %d, stderr %q", code, stderr)
+	}
+	if _, _, code := runMain(t, dir, "a.go"); code != 0 {
+		t.Errorf("non-strict exit code %d", code)
+	}
+}
+
+func TestStdin(t *testing.T) {
+	dir := t.TempDir()
+	src := "package api\n\nconst A = 1\n\n// {{A}}\n"
+	want := "package api\n\nconst A = 1\n\n// 1\n"
+	for _, args := range [][]string{{"-"}, {"--stdin"}} {
+		stdout, stderr, code := runMainInput(t, dir, src, args...)
+		if code != 0 {
+			t.Fatalf("%v: exit code %d: %s", args, code, stderr)
+		}
+		if stdout != want {
+			t.Errorf("%v: got %q, want %q", args, stdout, want)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("files written: %v", entries)
+	}
+}
+
+func TestCheckExitCode(t *testing.T) {
+	dir := t.TempDir()
+	src := "package api\n\nconst A = 1\n\n// {{A}}\n"
+	path := writeTestFile(t, dir, "a.go", src)
+	writeTestFile(t, dir, "b.go", "package api\n\n// done\n")
+
+	stdout, _, code := runMain(t, dir, "--check", ".")
+	if code != exitCheck {
+		t.Errorf("exit code %d, want %d", code, exitCheck)
+	}
+	if stdout != "a.go\n" {
+		t.Errorf("listed %q, want only a.go", stdout)
+	}
+	if got := readTestFile(t, path); got != src {
+		t.Errorf("file written by --check: %q", got)
+	}
+
+	if _, stderr, code := runMain(t, dir, "."); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	stdout, stderr, code := runMain(t, dir, "--check", ".")
+	if code != 0 || stdout != "" || stderr != "" {
+		t.Errorf("up-to-date tree: exit code %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+}
+
+func TestScopeFlag(t *testing.T) {
+	for _, tt := range []struct {
+		scope, want string
+	}{
+		{"file", "// {{Sibling}}\n"},
+		{"dir", "// 1\n"},
+	} {
+		dir := t.TempDir()
+		writeTestFile(t, dir, "a.go", "package api\n\nconst Sibling = 1\n")
+		b := writeTestFile(t, dir, "b.go", "package api\n\n// {{Sibling}}\n")
+		if _, stderr, code := runMain(t, dir, "--scope", tt.scope, "."); code != 0 {
+			t.Fatalf("--scope %s: exit code %d: %s", tt.scope, code, stderr)
+		}
+		if got := readTestFile(t, b); !strings.HasSuffix(got, tt.want) {
+			t.Errorf("--scope %s: got %q, want it to end with %q", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestMaxUnresolvedExitCode(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\n// {{One}} {{Two}}\n")
+	if _, stderr, code := runMain(t, dir, "--max-unresolved", "1", "."); code != exitUnresolved {
+		t.Errorf("exit code %d, want %d: %s", code, exitUnresolved, stderr)
+	}
+	if _, stderr, code := runMain(t, dir, "--max-unresolved", "2", "."); code != 0 {
+		t.Errorf("exit code %d, want 0: %s", code, stderr)
+	}
+}
+
+func TestListConstants(t *testing.T) {
+	dir := t.TempDir()
+	src := "package api\n\nconst StatusOK = 200\n\n// {{StatusOK}}\n"
+	path := writeTestFile(t, dir, "a.go", src)
+	stdout, stderr, code := runMain(t, dir, "--list-constants", ".")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	fields := strings.Fields(strings.Split(stdout, "\n")[1])
+	if len(fields) != 4 || fields[0] != "StatusOK" || fields[1] != "200" || fields[2] != "int" || fields[3] != "a.go:3" {
+		t.Errorf("unexpected listing:\n%s", stdout)
+	}
+	if got := readTestFile(t, path); got != src {
+		t.Errorf("file modified: %q", got)
+	}
+}
+
+func TestMultiplePaths(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "api/consts.go", "package api\n\nconst StatusOK = 200\n")
+	a := writeTestFile(t, dir, "api/a.go", "package api\n\n// {{StatusOK}}\n")
+	h := writeTestFile(t, dir, "handlers/h.go", "package handlers\n\n// {{StatusOK}}\n")
+	if _, stderr, code := runMain(t, dir, "--scope", "dir", "api/*.go", "handlers"); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	for _, path := range []string{a, h} {
+		if got := readTestFile(t, path); !strings.HasSuffix(got, "// 200\n") {
+			t.Errorf("%s: %q", path, got)
+		}
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"success", []string{"ok.go"}, 0},
+		{"no arguments", nil, exitUsage},
+		{"unknown flag", []string{"--no-such-flag", "ok.go"}, exitUsage},
+		{"parse error", []string{"broken.go"}, exitError},
+		{"missing file", []string{"missing.go"}, exitError},
+		{"strict", []string{"--strict", "unresolved.go"}, exitUnresolved},
+		{"check", []string{"--check", "pending.go"}, exitCheck},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFile(t, dir, "ok.go", "package api\n\n// nothing\n")
+			writeTestFile(t, dir, "broken.go", "package api\n\nfunc {\n")
+			writeTestFile(t, dir, "unresolved.go", "package api\n\n// {{Missing}}\n")
+			writeTestFile(t, dir, "pending.go", "package api\n\nconst A = 1\n\n// {{A}}\n")
+			if _, stderr, code := runMain(t, dir, tt.args...); code != tt.want {
+				t.Errorf("exit code %d, want %d: %s", code, tt.want, stderr)
+			}
+		})
+	}
+}
+
+func TestDiagnosticsOnStderr(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n\n// {{A}} {{Missing}}\n")
+
+	stdout, stderr, code := runMain(t, dir, "--verbose", "a.go")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	for _, diagnostic := range []string{"Processing:", "Replaced:", "Warning:"} {
+		if strings.Contains(stdout, diagnostic) {
+			t.Errorf("%s on stdout: %q", diagnostic, stdout)
+		}
+		if !strings.Contains(stderr, diagnostic) {
+			t.Errorf("%s missing from stderr: %q", diagnostic, stderr)
+		}
+	}
+
+	src := "package api\n\nconst B = 2\n\n// {{B}} {{Missing}}\n"
+	stdout, stderr, _ = runMainInput(t, dir, src, "--verbose", "-")
+	if stdout != "package api\n\nconst B = 2\n\n// 2 {{Missing}}\n" {
+		t.Errorf("stdout holds more than the source: %q", stdout)
+	}
+	if !strings.Contains(stderr, "Warning:") {
+		t.Errorf("no warning on stderr: %q", stderr)
+	}
+}
+
+func TestStatsFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\nconst Dead = 2\n\n// {{A}} {{A}}\n")
+	_, stderr, code := runMain(t, dir, "--stats", "a.go")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	if !strings.HasSuffix(stderr, "VARIABLE  USES\nA         2\nDead      0\n") {
+		t.Errorf("unexpected stats:\n%s", stderr)
+	}
+}
+
+func TestInStringsWarning(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n")
+	if _, stderr, _ := runMain(t, dir, "--in-strings", "a.go"); !strings.Contains(stderr, "Warning:") {
+		t.Errorf("no warning that code is modified: %q", stderr)
+	}
+	if _, stderr, _ := runMain(t, dir, "a.go"); strings.Contains(stderr, "Warning:") {
+		t.Errorf("unexpected warning: %q", stderr)
+	}
+}
+
+func TestQuietFlag(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n\n// {{A}} {{Missing}}\n")
+	for _, args := range [][]string{{"--quiet"}, {"--quiet", "--verbose"}} {
+		stdout, stderr, code := runMain(t, dir, append(args, "a.go")...)
+		if code != 0 {
+			t.Fatalf("%v: exit code %d: %s", args, code, stderr)
+		}
+		for _, info := range []string{"Processing:", "Replaced:", "Processed ", "Processing completed!"} {
+			if strings.Contains(stdout+stderr, info) {
+				t.Errorf("%v: %q printed: %q", args, info, stdout+stderr)
+			}
+		}
+		if !strings.Contains(stderr, "Warning:") || !strings.Contains(stderr, "Missing") {
+			t.Errorf("%v: warning suppressed: %q", args, stderr)
+		}
+	}
+}
+
+func TestFilesFromManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "api/consts.go", "package api\n\nconst Port = 8080\n")
+	a := writeTestFile(t, dir, "api/a.go", "package api\n\n// {{Port}}\n")
+	b := writeTestFile(t, dir, "api/b.go", "package api\n\n// {{Port}}\n")
+	writeTestFile(t, dir, "changed.txt", "\napi/a.go\n\n")
+
+	_, stderr, code := runMain(t, dir, "--files-from", "changed.txt", "--extract-root", ".")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	if got := readTestFile(t, a); got != "package api\n\n// 8080\n" {
+		t.Errorf("listed file not processed: %q", got)
+	}
+	if got := readTestFile(t, b); got != "package api\n\n// {{Port}}\n" {
+		t.Errorf("unlisted file processed: %q", got)
+	}
+
+	if _, _, code := runMain(t, dir, "--files-from", "missing.txt"); code != exitError {
+		t.Errorf("missing manifest: exit code %d", code)
+	}
+}
+
+func TestProcessTestsFlag(t *testing.T) {
+	for _, process := range []bool{false, true} {
+		dir := t.TempDir()
+		writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n")
+		test := writeTestFile(t, dir, "a_test.go", "package api\n\n// Example {{A}}\nfunc Example() {}\n")
+		args := []string{"--scope", "dir", "."}
+		if process {
+			args = append([]string{"--process-tests"}, args...)
+		}
+		if _, stderr, code := runMain(t, dir, args...); code != 0 {
+			t.Fatalf("exit code %d: %s", code, stderr)
+		}
+		if got := readTestFile(t, test); strings.Contains(got, "// Example 1\n") != process {
+			t.Errorf("--process-tests %v: %q", process, got)
+		}
+	}
+}
+
+func TestIndexFlags(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "consts/consts.go", "package consts\n\nconst Port = 8080\n")
+	a := writeTestFile(t, dir, "api/a.go", "package api\n\n// {{Port}}\n")
+
+	_, stderr, code := runMain(t, dir, "--index-out", "index.json", ".")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "Indexed 1 constant(s) in index.json") {
+		t.Errorf("unexpected output: %q", stderr)
+	}
+	if got := readTestFile(t, a); got != "package api\n\n// {{Port}}\n" {
+		t.Errorf("file written while indexing: %q", got)
+	}
+
+	if _, stderr, code := runMain(t, dir, "--index-in", "index.json", "api/a.go"); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	if got := readTestFile(t, a); got != "package api\n\n// 8080\n" {
+		t.Errorf("not substituted from the index: %q", got)
+	}
+}
+
+func TestStructFieldsScope(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestFile(t, dir, "a.go", "// Package api serves {{Name}}\npackage api\n\nconst Name = \"svc\"\n\ntype T struct {\n\tA int // {{Name}}\n}\n")
+	if _, stderr, code := runMain(t, dir, "--scope", "file,struct-fields", "a.go"); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	if got := readTestFile(t, a); got != "// Package api serves {{Name}}\npackage api\n\nconst Name = \"svc\"\n\ntype T struct {\n\tA int // svc\n}\n" {
+		t.Errorf("unexpected result: %q", got)
+	}
+	if _, _, code := runMain(t, dir, "--scope", "fields", "a.go"); code != exitUsage {
+		t.Errorf("unknown scope: exit code %d, want %d", code, exitUsage)
+	}
+}
+
+func TestLintPlaceholdersExitCode(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\nconst StatusOK = 200\n\n// {StatusOK}\n")
+	for _, tt := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{"--lint-placeholders"}, 0},
+		{[]string{"--lint-placeholders", "--strict"}, exitUnresolved},
+		{[]string{"--strict"}, 0},
+	} {
+		_, stderr, code := runMain(t, dir, append(tt.args, "a.go")...)
+		if code != tt.want {
+			t.Errorf("%v: exit code %d, want %d: %s", tt.args, code, tt.want, stderr)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package replacer
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// columnSeparator separates the columns of an aligned comment line
+var columnSeparator = regexp.MustCompile(` {2,}`)
+
+// commentLine is a full-line // comment split into aligned columns
+type commentLine struct {
+	prefix  string // indentation, slashes and the space after them
+	columns []string
+}
+
+// parseCommentLine splits a full-line // comment into columns, reporting
+// false for lines that aren't comments
+func parseCommentLine(line string) (commentLine, bool) {
+	trimmed := strings.TrimLeft(line, " \t")
+	if !strings.HasPrefix(trimmed, "//") {
+		return commentLine{}, false
+	}
+	text := strings.TrimLeft(trimmed[2:], " ")
+	prefix := line[:len(line)-len(text)]
+	return commentLine{prefix: prefix, columns: columnSeparator.Split(text, -1)}, true
+}
+
+// alignComments re-aligns the columns of each run of consecutive full-line
+// comments in which some line differs from original. Runs left untouched
+// by substitution keep their formatting.
+func alignComments(lines, original []string) {
+	for start := 0; start < len(lines); {
+		end := start
+		changed := false
+		for end < len(lines) {
+			if _, ok := parseCommentLine(lines[end]); !ok {
+				break
+			}
+			changed = changed || lines[end] != original[end]
+			end++
+		}
+		if changed {
+			alignRun(lines[start:end])
+		}
+		start = end + 1
+	}
+}
+
+// alignRun pads every column but the last of each line to the widest
+// entry of that column in the run, plus two spaces
+func alignRun(lines []string) {
+	parsed := make([]commentLine, len(lines))
+	var widths []int
+	for i, line := range lines {
+		parsed[i], _ = parseCommentLine(line)
+		columns := parsed[i].columns
+		for c := 0; c < len(columns)-1; c++ {
+			if c == len(widths) {
+				widths = append(widths, 0)
+			}
+			if w := utf8.RuneCountInString(columns[c]); w > widths[c] {
+				widths[c] = w
+			}
+		}
+	}
+
+	for i, p := range parsed {
+		if len(p.columns) < 2 {
+			continue
+		}
+		var b strings.Builder
+		b.WriteString(p.prefix)
+		for c, column := range p.columns {
+			b.WriteString(column)
+			if c < len(p.columns)-1 {
+				b.WriteString(strings.Repeat(" ", widths[c]-utf8.RuneCountInString(column)+2))
+			}
+		}
+		lines[i] = b.String()
+	}
+}
@@ -0,0 +1,61 @@
+package replacer
+
+import (
+	"testing"
+)
+
+func TestAlign(t *testing.T) {
+	src := `package api
+
+const Message = "a much longer message"
+
+// @Param  id    path   int     true  "ID"
+// @Param  name  query  string  true  "{{Message}}"
+// @Param  page  query  int     false "page"
+
+// untouched  block
+// stays      as is
+`
+	aligned := `package api
+
+const Message = "a much longer message"
+
+// @Param  id    path   int     true  "ID"
+// @Param  name  query  string  true  "a much longer message"
+// @Param  page  query  int     false "page"
+
+// untouched  block
+// stays      as is
+`
+	if got := process(t, NewSwaggerVariableReplacer(), src); got != aligned {
+		t.Errorf("without align:\n%s\nwant:\n%s", got, aligned)
+	}
+
+	r := NewSwaggerVariableReplacer()
+	r.SetAlign(true)
+	src = `package api
+
+const Type = "integer64"
+
+// @Param  id    path   int     true
+// @Param  name  query  {{Type}}  true
+// @Param  page  query  int     false
+
+// untouched  block
+// stays      as is
+`
+	want := `package api
+
+const Type = "integer64"
+
+// @Param  id    path   int        true
+// @Param  name  query  integer64  true
+// @Param  page  query  int        false
+
+// untouched  block
+// stays      as is
+`
+	if got := process(t, r, src); got != want {
+		t.Errorf("with align:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package replacer
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+)
+
+// SetASTComments makes substitution find comments by parsing each file, so
+// that only the bytes of the comments the Go parser reports are ever
+// edited, instead of scanning lines for comment markers. Files that don't
+// parse fall back to the line scanner.
+func (r *SwaggerVariableReplacer) SetASTComments(enabled bool) {
+	r.astComments = enabled
+}
+
+// astCommentSpans returns the comment spans of each line of content, as
+// parsed by go/parser; lines holds content split on "\n" with any "\r"
+// endings removed, so that spans never include them. Spans are located by
+// the actual positions of comments, ignoring //line directives, which only
+// change the positions reported for them.
+func astCommentSpans(filename string, content []byte, lines []string) ([][]commentSpan, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, content, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	starts := lineStarts(content)
+	spans := make([][]commentSpan, len(lines))
+	for _, group := range file.Comments {
+		addCommentGroup(spans, lines, starts, fset, group)
+	}
+	return spans, nil
+}
+
+// SetStructFieldComments restricts substitution to the doc and trailing
+// comments of struct fields, as located by the Go parser, leaving package,
+// type and function doc comments as well as string literals alone. Files
+// that don't parse aren't substituted.
+func (r *SwaggerVariableReplacer) SetStructFieldComments(enabled bool) {
+	r.structFields = enabled
+}
+
+// fieldCommentSpans returns the spans of the comments attached to struct
+// fields on each line of content, like astCommentSpans
+func fieldCommentSpans(filename string, content []byte, lines []string) ([][]commentSpan, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, content, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	starts := lineStarts(content)
+	spans := make([][]commentSpan, len(lines))
+	ast.Inspect(file, func(n ast.Node) bool {
+		if st, ok := n.(*ast.StructType); ok {
+			for _, field := range st.Fields.List {
+				addCommentGroup(spans, lines, starts, fset, field.Doc)
+				addCommentGroup(spans, lines, starts, fset, field.Comment)
+			}
+		}
+		return true
+	})
+	// Fields of a nested struct are found after the field holding it
+	for _, line := range spans {
+		sort.Slice(line, func(i, j int) bool {
+			return line[i].start < line[j].start
+		})
+	}
+	return spans, nil
+}
+
+// addCommentGroup adds the comments of group, which may be nil, to spans
+func addCommentGroup(spans [][]commentSpan, lines []string, starts []int, fset *token.FileSet, group *ast.CommentGroup) {
+	if group == nil {
+		return
+	}
+	for _, c := range group.List {
+		addSpan(spans, lines, starts, fset.PositionFor(c.Pos(), false), fset.PositionFor(c.End(), false), 0)
+	}
+}
+
+// lineStarts returns the offset of the start of each line in content
+func lineStarts(content []byte) []int {
+	starts := []int{0}
+	for i, b := range content {
+		if b == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// addSpan appends the [start, end) range of content to the spans of each
+// line it covers, as for block comments or raw strings spanning lines;
+// starts are the offsets of lines in content
+func addSpan(spans [][]commentSpan, lines []string, starts []int, start, end token.Position, quote byte) {
+	for line := start.Line; line <= end.Line; line++ {
+		i := line - 1
+		from, to := 0, len(lines[i])
+		if line == start.Line {
+			from = start.Offset - starts[i]
+		}
+		if line == end.Line && end.Offset-starts[i] < to {
+			to = end.Offset - starts[i]
+		}
+		spans[i] = append(spans[i], commentSpan{start: from, end: to, quote: quote})
+	}
+}
@@ -0,0 +1,145 @@
+package replacer
+
+import (
+	"os"
+	"testing"
+)
+
+func TestASTComments(t *testing.T) {
+	tests := []struct {
+		name, code, want string
+	}{
+		{"comment in raw string", "var s = `first\n// {{V}} in raw\n`\n", "var s = `first\n// {{V}} in raw\n`\n"},
+		{"block opener in raw string", "var s = `\n/* {{V}}\n` + \"*/\" // {{V}}\n", "var s = `\n/* {{V}}\n` + \"*/\" // v\n"},
+		{"backtick rune", "var r = '`' // {{V}}\n// {{V}}\n", "var r = '`' // v\n// v\n"},
+		{"quote rune", "var r = '\"' // {{V}}\n", "var r = '\"' // v\n"},
+		{"division after block comment", "var r = 1 /* {{V}} */ / 2 // {{V}}\n", "var r = 1 /* v */ / 2 // v\n"},
+		{"line comment in block comment", "/*\n// {{V}} */\nvar s = \"{{V}}\"\n", "/*\n// v */\nvar s = \"{{V}}\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "package api\n\nconst V = \"v\"\n\n" + tt.code
+			want := "package api\n\nconst V = \"v\"\n\n" + tt.want
+			r := NewSwaggerVariableReplacer()
+			r.SetASTComments(true)
+			if got := process(t, r, src); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestASTCommentsFallBackToScanner(t *testing.T) {
+	src := "package api\n\nconst V = \"v\"\n\nvar s = \"{{V}}\" // {{V}}\nfunc {\n"
+	spans, err := astCommentSpans("a.go", []byte(src), nil)
+	if err == nil || spans != nil {
+		t.Fatalf("unparseable source located: %v, %v", spans, err)
+	}
+
+	r := NewSwaggerVariableReplacer()
+	r.SetASTComments(true)
+	if err := r.ExtractConstantsFromSource([]byte("package api\n\nconst V = \"v\"\n")); err != nil {
+		t.Fatal(err)
+	}
+	content, res := r.substituteSource("a.go", []byte(src))
+	if want := "package api\n\nconst V = \"v\"\n\nvar s = \"{{V}}\" // v\nfunc {\n"; content != want || res.LinesChanged != 1 {
+		t.Errorf("got %q, want %q", content, want)
+	}
+}
+
+func TestLineDirectives(t *testing.T) {
+	src := "package api\n\n//line gen.tmpl:100\nconst V = \"v\"\n\nvar s = \"{{V}}\" // {{V}}\n\n/*line other.tmpl:1:1*/ var n = 1 /* {{V}} */\n// {{V}}\n"
+	tests := []struct {
+		name             string
+		ast, inStrings   bool
+		wantString, want string
+	}{
+		{"scanner", false, false, "{{V}}", "// v"},
+		{"ast", true, false, "{{V}}", "// v"},
+		{"ast in strings", true, true, "v", "// v"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestFile(t, t.TempDir(), "a.go", src)
+			r := NewSwaggerVariableReplacer()
+			r.SetASTComments(tt.ast)
+			r.SetInStrings(tt.inStrings)
+			capture(t, &os.Stderr, func() {
+				if _, err := r.ProcessFile(path); err != nil {
+					t.Fatal(err)
+				}
+			})
+			want := "package api\n\n//line gen.tmpl:100\nconst V = \"v\"\n\nvar s = \"" + tt.wantString + "\" " + tt.want + "\n\n/*line other.tmpl:1:1*/ var n = 1 /* v */\n" + tt.want + "\n"
+			if got := readTestFile(t, path); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+			if got := r.Constants()[0].Location; got != path+":4" {
+				t.Errorf("V located at %s, want %s:4", got, path)
+			}
+		})
+	}
+}
+
+func TestLineDirectivesLeftIntact(t *testing.T) {
+	for _, line := range []string{
+		"//line {{V}}.go:1",
+		"/*line {{V}}.go:1*/ var y int",
+	} {
+		src := "package api\n\nconst V = \"v\"\n\n" + line + "\nvar x int\n"
+		if got := process(t, NewSwaggerVariableReplacer(), src); got != src {
+			t.Errorf("%s: got %q", line, got)
+		}
+	}
+}
+
+func TestStructFieldComments(t *testing.T) {
+	src := `// Package api serves {{Name}}
+package api
+
+const Name = "svc"
+
+// Request is sent to {{Name}}
+type Request struct {
+	// ID names a {{Name}} item
+	ID string ` + "`json:\"id\"`" + ` // of {{Name}}
+	Nested struct {
+		Key string // {{Name}} key
+	}
+	Label string // "{{Name}}"
+}
+
+// Handle handles {{Name}} requests
+func Handle() string {
+	return "{{Name}}" // {{Name}}
+}
+`
+	want := `// Package api serves {{Name}}
+package api
+
+const Name = "svc"
+
+// Request is sent to {{Name}}
+type Request struct {
+	// ID names a svc item
+	ID string ` + "`json:\"id\"`" + ` // of svc
+	Nested struct {
+		Key string // svc key
+	}
+	Label string // "svc"
+}
+
+// Handle handles {{Name}} requests
+func Handle() string {
+	return "{{Name}}" // {{Name}}
+}
+`
+	for _, inStrings := range []bool{false, true} {
+		r := NewSwaggerVariableReplacer()
+		r.SetStructFieldComments(true)
+		r.SetInStrings(inStrings)
+		if got := process(t, r, src); got != want {
+			t.Errorf("in strings %v: got\n%s\nwant\n%s", inStrings, got, want)
+		}
+	}
+
+}
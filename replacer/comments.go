@@ -0,0 +1,422 @@
+package replacer
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// commentScanner tracks lexical state across lines so that comments can
+// be told apart from `//` and `/*` inside string, raw string and rune
+// literals
+type commentScanner struct {
+	inRawString    bool // a raw string literal continues from a previous line
+	inBlockComment bool // a /* */ comment continues from a previous line
+}
+
+// commentSpan is a [start, end) byte range of comment text within a line,
+// or of the contents of a string literal with SetInStrings
+type commentSpan struct {
+	start, end int
+	quote      byte // the quote of a string literal, 0 for comments
+}
+
+// commentSpans returns the ranges of line that belong to line or block
+// comments, in order
+func (s *commentScanner) commentSpans(line string) []commentSpan {
+	var spans []commentSpan
+	i := 0
+	switch {
+	case s.inBlockComment:
+		end := strings.Index(line, "*/")
+		if end < 0 {
+			return []commentSpan{{start: 0, end: len(line)}}
+		}
+		s.inBlockComment = false
+		spans = append(spans, commentSpan{start: 0, end: end + 2})
+		i = end + 2
+	case s.inRawString:
+		end := strings.IndexByte(line, '`')
+		if end < 0 {
+			return nil
+		}
+		s.inRawString = false
+		i = end + 1
+	}
+
+	for ; i < len(line); i++ {
+		switch line[i] {
+		case '"', '\'':
+			i = skipQuoted(line, i)
+		case '`':
+			end := strings.IndexByte(line[i+1:], '`')
+			if end < 0 {
+				s.inRawString = true
+				return spans
+			}
+			i += end + 1
+		case '/':
+			if i+1 >= len(line) {
+				break
+			}
+			switch line[i+1] {
+			case '/':
+				return append(spans, commentSpan{start: i, end: len(line)})
+			case '*':
+				end := strings.Index(line[i+2:], "*/")
+				if end < 0 {
+					s.inBlockComment = true
+					return append(spans, commentSpan{start: i, end: len(line)})
+				}
+				spans = append(spans, commentSpan{start: i, end: i + 2 + end + 2})
+				i += 2 + end + 1
+			}
+		}
+	}
+	return spans
+}
+
+// skipQuoted returns the index of the quote closing the interpreted string
+// or rune literal opened at line[start], or the last index of an
+// unterminated literal
+func skipQuoted(line string, start int) int {
+	quote := line[start]
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case quote:
+			return i
+		}
+	}
+	return len(line) - 1
+}
+
+// processCommentSpans replaces variables within the comment spans of line,
+// or values with their placeholders in reverse mode, leaving the code around
+// them and compiler directives untouched. String literal spans are only
+// substituted, never reversed.
+func (r *SwaggerVariableReplacer) processCommentSpans(line string, spans []commentSpan, lineNo int, res *Result) string {
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	var b strings.Builder
+	last := 0
+	for _, span := range spans {
+		text := line[span.start:span.end]
+		// Lines a multiline value continues onto must stay inside the
+		// comment: line comments need their own slashes, block comments
+		// only the indentation
+		continuation := indent
+		if strings.HasPrefix(text, "//") {
+			continuation = indent + "// "
+		}
+		render := func(value string) (string, bool) {
+			return strings.ReplaceAll(value, "\n", "\n"+continuation), true
+		}
+		if span.quote != 0 {
+			render = func(value string) (string, bool) {
+				return quoteValue(value, span.quote)
+			}
+		}
+
+		b.WriteString(line[last:span.start])
+		switch {
+		case span.quote == 0 && isCompilerDirective(text), span.quote != 0 && r.reverse != nil:
+			b.WriteString(text)
+		case r.reverse != nil:
+			b.WriteString(r.reverseCommentLine(text, lineNo, res))
+		default:
+			b.WriteString(r.processCommentLine(text, render, lineNo, span.start, res))
+		}
+		last = span.end
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+// splitOpenerPattern matches a built-in placeholder left open at the end of
+// a comment line, as when a long line is wrapped at a space inside it
+var splitOpenerPattern = regexp.MustCompile(`(?:\{\{|\$\{|@VAR\()\s*[A-Za-z0-9_.]*$`)
+
+// joinSplitPlaceholder mends a placeholder wrapped across two line
+// comments, such as "{{" ending line and "APIVersion}}" starting next, by
+// moving its end up to line; the rest of next stays on its own line. Lines
+// are only changed when the mended placeholder resolves, and comment is
+// the last comment span of line, which must be a line comment.
+func (r *SwaggerVariableReplacer) joinSplitPlaceholder(filename, line string, comment commentSpan, next string) (string, string, bool) {
+	text := line[comment.start:comment.end]
+	if comment.quote != 0 || !strings.HasPrefix(text, "//") || comment.end != len(line) || isCompilerDirective(text) {
+		return "", "", false
+	}
+	opener := splitOpenerPattern.FindStringIndex(text)
+	if opener == nil {
+		return "", "", false
+	}
+	indent := next[:len(next)-len(strings.TrimLeft(next, " \t"))]
+	if !strings.HasPrefix(next[len(indent):], "//") {
+		return "", "", false
+	}
+
+	fragment := text[opener[0]:]
+	candidate := fragment + " " + strings.TrimLeft(next[len(indent)+2:], " \t")
+	placeholders := r.findPlaceholders(candidate)
+	if len(placeholders) == 0 || placeholders[0].start != 0 || placeholders[0].end <= len(fragment)+1 {
+		return "", "", false
+	}
+	p := placeholders[0]
+	if _, exists := r.lookup(filename, p.name); !exists && !p.hasDefault {
+		return "", "", false
+	}
+
+	joined := line[:comment.start+opener[0]] + candidate[:p.end]
+	next = indent + "//"
+	if rest := strings.TrimLeft(candidate[p.end:], " \t"); rest != "" {
+		next += " " + rest
+	}
+	return joined, next, true
+}
+
+// validate checks sub with the validator, if any, recording a rejection
+// in res, and warning about it unless in strict mode
+func (r *SwaggerVariableReplacer) validate(sub Substitution, res *Result) {
+	if r.validator == nil {
+		return
+	}
+	if err := r.validator(sub); err != nil {
+		err = fmt.Errorf("%s:%d: invalid value %s for %s: %v", sub.File, sub.Line, sub.New, sub.Name, err)
+		res.invalid = append(res.invalid, err)
+		if !r.strict {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+}
+
+// isCompilerDirective reports whether comment is a //go: directive other
+// than //go:generate, such as //go:build or //go:embed, or a //line
+// directive, which must be left intact for builds to keep working and
+// positions to stay right
+func isCompilerDirective(comment string) bool {
+	if strings.HasPrefix(comment, "//line ") || strings.HasPrefix(comment, "/*line ") {
+		return true
+	}
+	return strings.HasPrefix(comment, "//go:") && !strings.HasPrefix(comment, "//go:generate ")
+}
+
+// placeholder is a variable reference found in comment text
+type placeholder struct {
+	start, end int // byte range of the whole placeholder
+	name       string
+	style      string
+	fallback   string // value to use if name can't be resolved
+	hasDefault bool   // whether the placeholder gave a fallback
+	format     string // transform to apply to the value, if any
+}
+
+// expandValue resolves the placeholders inside text, the value of the last
+// of the variables in stack, recursively until the depth set with
+// SetRecursiveResolve. Placeholders that can't be resolved are kept, and so
+// are those naming a variable in stack, which would never end.
+func (r *SwaggerVariableReplacer) expandValue(file, text string, stack []string) string {
+	if len(stack) > r.recursiveDepth {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, p := range r.findPlaceholders(text) {
+		if p.start > 0 && text[p.start-1] == '\\' {
+			continue
+		}
+		cycle := false
+		for _, name := range stack {
+			cycle = cycle || name == p.name
+		}
+		if cycle {
+			fmt.Fprintf(os.Stderr, "Warning: Variable cycle %s -> %s left unresolved\n", strings.Join(stack, " -> "), p.name)
+			continue
+		}
+		info, exists := r.lookup(file, p.name)
+		if !exists && p.hasDefault {
+			info, exists = ConstantInfo{Value: p.fallback}, true
+		}
+		if !exists {
+			continue
+		}
+		value := r.expandValue(file, r.formatValue(p.name, info), append(stack[:len(stack):len(stack)], p.name))
+		if p.format != "" {
+			value = r.transform(p, info.Value, value)
+		}
+		b.WriteString(text[last:p.start])
+		b.WriteString(value)
+		last = p.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// findPlaceholders returns the non-overlapping placeholders of every pattern
+// in text, ordered by position. When placeholders overlap, the one starting
+// first wins, then the one from the earlier pattern.
+func (r *SwaggerVariableReplacer) findPlaceholders(text string) []placeholder {
+	var found []placeholder
+	for _, pattern := range r.patterns {
+		for _, m := range pattern.re.FindAllStringSubmatchIndex(text, -1) {
+			if len(m) >= 4 && m[2] >= 0 {
+				p := placeholder{start: m[0], end: m[1], name: text[m[2]:m[3]], style: pattern.style}
+				// Only built-in patterns have a second group: the format of
+				// braces, and the optional quoted default of @VAR
+				if len(m) >= 6 && m[4] >= 0 {
+					switch pattern.style {
+					case "braces":
+						p.format = text[m[4]:m[5]]
+					case "var":
+						quoted := text[m[4]:m[5]]
+						p.fallback, p.hasDefault = quoted[1:len(quoted)-1], true
+						if unquoted, err := strconv.Unquote(quoted); err == nil {
+							p.fallback = unquoted
+						}
+					}
+				}
+				found = append(found, p)
+			}
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].start < found[j].start
+	})
+
+	var placeholders []placeholder
+	end := 0
+	for _, p := range found {
+		if p.start >= end {
+			placeholders = append(placeholders, p)
+			end = p.end
+		}
+	}
+	return placeholders
+}
+
+// formatValue renders the value of the variable name for substitution,
+// through the formatter set with SetFormatter if any
+func (r *SwaggerVariableReplacer) formatValue(name string, info ConstantInfo) string {
+	if r.formatter != nil {
+		return r.formatter(name, info.Value)
+	}
+	if stringName, exists := r.stringNames[name]; exists && r.stringer {
+		return stringName
+	}
+	if v, isFloat := info.Value.(float64); isFloat && r.floatFormat != "" {
+		return fmt.Sprintf(r.floatFormat, v)
+	}
+	// Prefer the literal as written, so 3.14000 isn't reformatted as 3.14
+	if info.Raw != "" {
+		return info.Raw
+	}
+	switch v := info.Value.(type) {
+	case string:
+		return fmt.Sprintf("%s", v)
+	case rune:
+		if r.runeCodes {
+			return fmt.Sprintf("%d", v)
+		}
+		return string(v)
+	case bool:
+		if v {
+			return r.boolTrue
+		}
+		return r.boolFalse
+	}
+	return fmt.Sprintf("%v", info.Value)
+}
+
+// processCommentLine processes a single comment line and replaces variables,
+// recording substitutions and unresolved variables in res. All patterns are
+// matched against the original text in a single pass, so substituted values
+// are never substituted again. A placeholder preceded by a backslash, as in
+// \{{Name}}, is escaped: it is written literally without the backslash. As
+// the placeholder is then live again, a later run over the output
+// substitutes it, so escapes belong in sources that are never overwritten,
+// or must be escaped again before rerunning. With SetReplaceOnce, only the
+// first occurrence of each placeholder is substituted, resolved or not, and
+// its repeats are kept literally too. Values go through render, which makes
+// them fit where the line is, as by continuing multiline values inside the
+// comment, or reports false to keep the placeholder.
+func (r *SwaggerVariableReplacer) processCommentLine(line string, render func(value string) (string, bool), lineNo, offset int, res *Result) string {
+	var b strings.Builder
+	last := 0
+
+	placeholders := r.findPlaceholders(line)
+	if r.lintPlaceholders {
+		r.lintComment(line, placeholders, lineNo, offset, res)
+	}
+	// Placeholders already seen on the line, as written, in replace-once
+	// mode
+	seen := make(map[string]bool)
+	for _, p := range placeholders {
+		match := line[p.start:p.end]
+		if p.start > 0 && line[p.start-1] == '\\' {
+			b.WriteString(line[last : p.start-1])
+			b.WriteString(match)
+			last = p.end
+			continue
+		}
+		if r.replaceOnce && seen[match] {
+			continue // kept as written with the text around it
+		}
+		seen[match] = true
+
+		replacement := match // Keep original if not found
+		info, exists := r.lookup(res.File, p.name)
+		if !exists && p.hasDefault {
+			info, exists = ConstantInfo{Value: p.fallback}, true
+		}
+		if exists {
+			text := r.formatValue(p.name, info)
+			if r.recursiveDepth > 0 {
+				text = r.expandValue(res.File, text, []string{p.name})
+			}
+			if p.format != "" {
+				text = r.transform(p, info.Value, text)
+			}
+			rendered, ok := render(text)
+			if !ok {
+				if !r.gccWarning(res.File, lineNo, offset+p.start+1, "variable '%s' can't be substituted here", p.name) {
+					fmt.Fprintf(os.Stderr, "Warning: Variable '%s' can't be substituted here\n", p.name)
+				}
+				b.WriteString(line[last:p.end])
+				last = p.end
+				continue
+			}
+			replacement = rendered
+			sub := Substitution{
+				Pattern:    p.style,
+				Name:       p.name,
+				Value:      info.Value,
+				Old:        match,
+				New:        replacement,
+				File:       res.File,
+				Line:       lineNo,
+				Definition: info.Location(),
+			}
+			r.validate(sub, res)
+			res.Substitutions = append(res.Substitutions, sub)
+		} else {
+			if r.warningFormat != "gcc" {
+				r.logf("Warning: Variable '%s' not found at %s:%d\n", p.name, res.File, lineNo)
+			}
+			if list, index, ok := r.outOfRange(res.File, p.name); ok {
+				if !r.gccWarning(res.File, lineNo, offset+p.start+1, "index %s of '%s' out of range", index, list) {
+					fmt.Fprintf(os.Stderr, "Warning: Index %s of '%s' out of range at %s:%d\n", index, list, res.File, lineNo)
+				}
+			}
+			res.addUnresolved(p.name, match, lineNo, offset+p.start+1)
+		}
+		b.WriteString(line[last:p.start])
+		b.WriteString(replacement)
+		last = p.end
+	}
+	b.WriteString(line[last:])
+
+	return b.String()
+}
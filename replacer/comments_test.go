@@ -0,0 +1,580 @@
+package replacer
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEscapedPlaceholder(t *testing.T) {
+	src := "package api\n\nconst A = 1\n\n// {{A}} \\{{A}} \\${A} \\@VAR(A)\n"
+	path := writeTestFile(t, t.TempDir(), "a.go", src)
+	for run, want := range []string{
+		"package api\n\nconst A = 1\n\n// 1 {{A}} ${A} @VAR(A)\n",
+		// The escape is consumed, so a rerun substitutes what it left
+		"package api\n\nconst A = 1\n\n// 1 1 1 1\n",
+	} {
+		if _, err := NewSwaggerVariableReplacer().ProcessFile(path); err != nil {
+			t.Fatal(err)
+		}
+		if got := readTestFile(t, path); got != want {
+			t.Fatalf("run %d: got %q, want %q", run+1, got, want)
+		}
+	}
+}
+
+func TestTrailingCommentAfterCode(t *testing.T) {
+	tests := []struct {
+		name, code string
+	}{
+		{"string with //", `var URL = "http://localhost/api/" + V`},
+		{"raw string with //", "var Pattern = `//+`"},
+		{"string with /*", `var Open = "/*"`},
+		{"rune slash", `var Sep = '/'`},
+		{"escaped quote", `var Quote = "\"//"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "package api\n\nconst V = \"v1\"\n\n" + tt.code + " // serves {{V}}\n"
+			want := "package api\n\nconst V = \"v1\"\n\n" + tt.code + " // serves v1\n"
+			if got := process(t, NewSwaggerVariableReplacer(), src); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestReplaceOnce(t *testing.T) {
+	tests := []struct {
+		comment, want string
+	}{
+		{"{{X}} and {{X}} and ${X}", "1 and {{X}} and 1"},
+		{"{{Nope}} then {{X}} then {{X}}", "{{Nope}} then 1 then {{X}}"},
+		{"\\{{X}} then {{X}} then {{X}}", "{{X}} then 1 then {{X}}"},
+		{"{{N:hex}} and {{A:upper}}", "0x5 and X"},
+	}
+	for _, tt := range tests {
+		r := NewSwaggerVariableReplacer()
+		r.SetReplaceOnce(true)
+		src := "package api\n\nconst X = 1\nconst N = 5\nconst A = \"x\"\n\n// " + tt.comment + "\n// {{X}}\n"
+		want := "package api\n\nconst X = 1\nconst N = 5\nconst A = \"x\"\n\n// " + tt.want + "\n// 1\n"
+		if got := process(t, r, src); got != want {
+			t.Errorf("%s: got %q, want %q", tt.comment, got, want)
+		}
+	}
+}
+
+func TestReplaceOnceIdempotent(t *testing.T) {
+	path := writeTestFile(t, t.TempDir(), "a.go", "package api\n\nconst N = 5\nconst A = \"x\"\n\n// {{N:hex}} and {{A:upper}} and ${N}\n")
+	r := NewSwaggerVariableReplacer()
+	r.SetReplaceOnce(true)
+	capture(t, &os.Stderr, func() {
+		if _, err := r.ProcessFile(path); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if got := readTestFile(t, path); !strings.HasSuffix(got, "// 0x5 and X and 5\n") {
+		t.Fatalf("first run: %q", got)
+	}
+	if lines, err := r.DryRun(path); err != nil || lines != 0 {
+		t.Errorf("second run would change %d line(s): %v", lines, err)
+	}
+}
+
+func TestCommentDetection(t *testing.T) {
+	tests := []struct {
+		name, line, want string
+	}{
+		{"url in string", `var u = "https://{{V}}/path"`, `var u = "https://{{V}}/path"`},
+		{"placeholder in string", `var s = "{{V}}"`, `var s = "{{V}}"`},
+		{"placeholder in rune context", `var r, s = '"', "{{V}}"`, `var r, s = '"', "{{V}}"`},
+		{"comment after url", `var u = "https://{{V}}/path" // {{V}}`, `var u = "https://{{V}}/path" // v1`},
+		{"comment after code", `var x = f() // version {{V}}`, `var x = f() // version v1`},
+		{"whole-line comment", `// {{V}}`, `// v1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "package api\n\nconst V = \"v1\"\n\n" + tt.line + "\n"
+			want := "package api\n\nconst V = \"v1\"\n\n" + tt.want + "\n"
+			if got := process(t, NewSwaggerVariableReplacer(), src); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestBlockComments(t *testing.T) {
+	tests := []struct {
+		name, code, want string
+	}{
+		{"single line", "/* Version ${V} */", "/* Version v1 */"},
+		{"after code", "var x = 1 /* {{V}} */", "var x = 1 /* v1 */"},
+		{
+			"multiline",
+			"/*\nAPI ${V}\n  served at /api/{{V}}\n*/",
+			"/*\nAPI v1\n  served at /api/v1\n*/",
+		},
+		{"code after block", "/* {{V}} */ var x = \"{{V}}\"", "/* v1 */ var x = \"{{V}}\""},
+		{"opener in string", "var s = \"/* {{V}}\" // {{V}}\nvar t = \"{{V}} */\"", "var s = \"/* {{V}}\" // v1\nvar t = \"{{V}} */\""},
+		{"opener in line comment", "// /* {{V}}\nvar t = \"{{V}}\"", "// /* v1\nvar t = \"{{V}}\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "package api\n\nconst V = \"v1\"\n\n" + tt.code + "\n"
+			want := "package api\n\nconst V = \"v1\"\n\n" + tt.want + "\n"
+			if got := process(t, NewSwaggerVariableReplacer(), src); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSubstitutedValuesNotResubstituted(t *testing.T) {
+	src := `package api
+
+const Template = "${x} and {{Y}}"
+const Y = 2
+const Path = "@VAR(Y)"
+
+// {{Template}} {{Y}} ${Path}
+`
+	want := `package api
+
+const Template = "${x} and {{Y}}"
+const Y = 2
+const Path = "@VAR(Y)"
+
+// ${x} and {{Y}} 2 @VAR(Y)
+`
+	if got := process(t, NewSwaggerVariableReplacer(), src); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSubstitutionIdempotent(t *testing.T) {
+	src := `package api
+
+const StatusOK = 200
+const Message = "OK: done"
+
+// @Success {{StatusOK}} {string} string "{{Message}}"
+// @Router /items/${StatusOK} [get] @VAR(Message)
+`
+	path := writeTestFile(t, t.TempDir(), "a.go", src)
+	if _, err := NewSwaggerVariableReplacer().ProcessFile(path); err != nil {
+		t.Fatal(err)
+	}
+	first := readTestFile(t, path)
+	if first == src {
+		t.Fatal("first run changed nothing")
+	}
+	if _, err := NewSwaggerVariableReplacer().ProcessFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if second := readTestFile(t, path); second != first {
+		t.Errorf("second run changed the file:\n%s\nwant:\n%s", second, first)
+	}
+}
+
+func TestMultilineValue(t *testing.T) {
+	tests := []struct {
+		name, comment, want string
+	}{
+		{"line comment", "// Notes: {{Notes}}", "// Notes: first\n// second"},
+		{"indented line comment", "\t// {{Notes}}", "\t// first\n\t// second"},
+		{"block comment", "/* Notes: {{Notes}} */", "/* Notes: first\nsecond */"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "package api\n\nconst Notes = `first\nsecond`\n\n" + tt.comment + "\n"
+			want := "package api\n\nconst Notes = `first\nsecond`\n\n" + tt.want + "\n"
+			if got := process(t, NewSwaggerVariableReplacer(), src); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+	if got, _ := extracted(t, "package api\n\nconst Notes = `first\nsecond`\n", "Notes"); got != "first\nsecond" {
+		t.Errorf("extracted %q with comment markers", got)
+	}
+}
+
+func TestFloatFormat(t *testing.T) {
+	src := "package api\n\nconst Small = 0.0000001\nconst Large = 12345678.9\nconst Whole = 3.0\nconst Sci = 1e6\n\n// {{Small}} {{Large}} {{Whole}} {{Sci}}\n"
+	tests := []struct {
+		format, want string
+	}{
+		{"", "0.0000001 12345678.9 3.0 1e6"},
+		{"%g", "1e-07 1.23456789e+07 3 1e+06"},
+		{"%.2f", "0.00 12345678.90 3.00 1000000.00"},
+	}
+	for _, tt := range tests {
+		r := NewSwaggerVariableReplacer()
+		r.SetFloatFormat(tt.format)
+		got := process(t, r, src)
+		if want := "// " + tt.want + "\n"; !strings.HasSuffix(got, want) {
+			t.Errorf("format %q: got %q, want it to end with %q", tt.format, got, want)
+		}
+	}
+}
+
+func TestRawLiteralText(t *testing.T) {
+	src := "package api\n\nconst Pi = 3.14000\nconst Mask = 0xFF\nconst Big = 1_000\n\n// {{Pi}} {{Mask}} {{Big}}\n"
+	if got := process(t, NewSwaggerVariableReplacer(), src); !strings.HasSuffix(got, "// 3.14000 0xFF 1_000\n") {
+		t.Errorf("raw text not substituted: %q", got)
+	}
+	if got, _ := extracted(t, src, "Pi"); got != 3.14 {
+		t.Errorf("parsed Pi = %v, want 3.14", got)
+	}
+
+	// A constant map override wins over the raw text
+	r := NewSwaggerVariableReplacer()
+	r.SetOverride(true)
+	if err := r.ApplyConfig(&Config{ConstantMap: map[string]string{"Pi": "3.14"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := process(t, r, src); !strings.HasSuffix(got, "// 3.14 0xFF 1_000\n") {
+		t.Errorf("override not substituted: %q", got)
+	}
+
+	// An explicit format formats the parsed value
+	r = NewSwaggerVariableReplacer()
+	r.SetFloatFormat("%v")
+	if got := process(t, r, src); !strings.HasSuffix(got, "// 3.14 0xFF 1_000\n") {
+		t.Errorf("parsed value not formatted: %q", got)
+	}
+}
+
+func TestSignedLiteralText(t *testing.T) {
+	src := "package api\n\nconst Rate = -0.50\nconst Neg = -0x10\nconst Pos = +7\nconst Twice = - -1\n\n// {{Rate}} {{Neg}} {{Pos}} {{Twice}}\n"
+	if got := process(t, NewSwaggerVariableReplacer(), src); !strings.HasSuffix(got, "// -0.50 -0x10 +7 1\n") {
+		t.Errorf("signed literals not substituted as written: %q", got)
+	}
+	if got, _ := extracted(t, src, "Neg"); got != -16 {
+		t.Errorf("parsed Neg = %v, want -16", got)
+	}
+}
+
+func TestCustomPatterns(t *testing.T) {
+	src := "package api\n\nconst Name = \"svc\"\n\n// %(Name)s {{Name}}\n"
+
+	r := NewSwaggerVariableReplacer()
+	if err := r.AddPattern(`%\((\w+)\)s`); err != nil {
+		t.Fatal(err)
+	}
+	if got := process(t, r, src); !strings.HasSuffix(got, "// svc svc\n") {
+		t.Errorf("AddPattern: got %q", got)
+	}
+
+	r = NewSwaggerVariableReplacer()
+	if err := r.SetPatterns([]string{`%\((\w+)\)s`}); err != nil {
+		t.Fatal(err)
+	}
+	if got := process(t, r, src); !strings.HasSuffix(got, "// svc {{Name}}\n") {
+		t.Errorf("SetPatterns: got %q", got)
+	}
+
+	for _, expr := range []string{`%\(\w+\)s`, `%\((\w+)\)(s)`, `%\((\w+`} {
+		if err := NewSwaggerVariableReplacer().AddPattern(expr); err == nil {
+			t.Errorf("AddPattern(%q) accepted", expr)
+		}
+		if err := NewSwaggerVariableReplacer().SetPatterns([]string{expr}); err == nil {
+			t.Errorf("SetPatterns(%q) accepted", expr)
+		}
+	}
+}
+
+func TestEnvFallback(t *testing.T) {
+	t.Setenv("BUILD_SHA", "abc123")
+	t.Setenv("Version", "from-env")
+	src := "package api\n\nconst Version = \"v1\"\n\n// {{BUILD_SHA}} {{Version}} {{UNSET_FOR_TEST}}\n"
+
+	r := NewSwaggerVariableReplacer()
+	r.SetEnv(true)
+	var got string
+	stderr := capture(t, &os.Stderr, func() { got = process(t, r, src) })
+	if !strings.HasSuffix(got, "// abc123 v1 {{UNSET_FOR_TEST}}\n") {
+		t.Errorf("got %q", got)
+	}
+	if strings.Contains(stderr, "BUILD_SHA") || !strings.Contains(stderr, "'UNSET_FOR_TEST' not found") {
+		t.Errorf("unexpected warnings %q", stderr)
+	}
+
+	if got := process(t, NewSwaggerVariableReplacer(), src); !strings.HasSuffix(got, "// {{BUILD_SHA}} v1 {{UNSET_FOR_TEST}}\n") {
+		t.Errorf("environment used without SetEnv: %q", got)
+	}
+}
+
+func TestVarDefault(t *testing.T) {
+	tests := []struct {
+		comment, want string
+		warned        bool
+	}{
+		{`@VAR(Name, "fallback")`, "svc", false},
+		{`@VAR(Missing, "fallback")`, "fallback", false},
+		{`@VAR(Missing, "with \"quotes\"")`, `with "quotes"`, false},
+		{`@VAR(Missing)`, "@VAR(Missing)", true},
+	}
+	for _, tt := range tests {
+		src := "package api\n\nconst Name = \"svc\"\n\n// " + tt.comment + "\n"
+		var got string
+		stderr := capture(t, &os.Stderr, func() { got = process(t, NewSwaggerVariableReplacer(), src) })
+		if want := "// " + tt.want + "\n"; !strings.HasSuffix(got, want) {
+			t.Errorf("%s: got %q, want it to end with %q", tt.comment, got, want)
+		}
+		if warned := strings.Contains(stderr, "Warning"); warned != tt.warned {
+			t.Errorf("%s: warnings %q", tt.comment, stderr)
+		}
+	}
+}
+
+func TestRuneConstants(t *testing.T) {
+	src := "package api\n\nconst Sep = '/'\nconst Tab = '\\t'\nconst Quote = '\\''\nconst Euro = '€'\nconst Smile = '\\U0001F600'\n\n// [{{Sep}}] [{{Tab}}] [{{Quote}}] [{{Euro}}] [{{Smile}}]\n"
+	tests := []struct {
+		codes bool
+		want  string
+	}{
+		{false, "// [/] [\t] ['] [€] [😀]\n"},
+		{true, "// [47] [9] [39] [8364] [128512]\n"},
+	}
+	for _, tt := range tests {
+		r := NewSwaggerVariableReplacer()
+		r.SetRuneCodes(tt.codes)
+		if got := process(t, r, src); !strings.HasSuffix(got, tt.want) {
+			t.Errorf("codes %v: got %q, want it to end with %q", tt.codes, got, tt.want)
+		}
+	}
+}
+
+func TestSpacedPlaceholders(t *testing.T) {
+	for _, comment := range []string{
+		"{{StatusOK}}", "{{ StatusOK }}", "{{StatusOK  }}", "{{\tStatusOK}}",
+		"${StatusOK}", "${ StatusOK }",
+		"@VAR(StatusOK)", "@VAR( StatusOK )",
+	} {
+		src := "package api\n\nconst StatusOK = 200\n\n// " + comment + "\n"
+		if got := process(t, NewSwaggerVariableReplacer(), src); !strings.HasSuffix(got, "// 200\n") {
+			t.Errorf("%s: got %q", comment, got)
+		}
+	}
+}
+
+func TestBuiltinPatterns(t *testing.T) {
+	src := "package api\n\nconst X = 1\n\n// {{X}} ${X} @VAR(X)\n"
+	tests := []struct {
+		styles []string
+		want   string
+	}{
+		{[]string{"braces"}, "// 1 ${X} @VAR(X)\n"},
+		{[]string{"dollar", "var"}, "// {{X}} 1 1\n"},
+		{[]string{"braces", "dollar", "var"}, "// 1 1 1\n"},
+	}
+	for _, tt := range tests {
+		r := NewSwaggerVariableReplacer()
+		if err := r.SetBuiltinPatterns(tt.styles); err != nil {
+			t.Fatal(err)
+		}
+		if got := process(t, r, src); !strings.HasSuffix(got, tt.want) {
+			t.Errorf("%v: got %q, want it to end with %q", tt.styles, got, tt.want)
+		}
+	}
+	if err := NewSwaggerVariableReplacer().SetBuiltinPatterns([]string{"percent"}); err == nil {
+		t.Error("unknown style accepted")
+	}
+}
+
+func TestFormatter(t *testing.T) {
+	src := "package api\n\nconst Mask = 255\nconst Name = \"svc\"\n\n// {{Mask}} {{Name}}\n"
+	r := NewSwaggerVariableReplacer()
+	r.SetFormatter(func(name string, value interface{}) string {
+		switch v := value.(type) {
+		case int:
+			return fmt.Sprintf("%#x", v)
+		case string:
+			return strconv.Quote(name + "=" + v)
+		}
+		return fmt.Sprint(value)
+	})
+	if got := process(t, r, src); !strings.HasSuffix(got, "// 0xff \"Name=svc\"\n") {
+		t.Errorf("formatter not applied: %q", got)
+	}
+
+	r.SetFormatter(nil)
+	if got := process(t, r, src); !strings.HasSuffix(got, "// 255 svc\n") {
+		t.Errorf("default formatting not restored: %q", got)
+	}
+}
+
+func TestDirectives(t *testing.T) {
+	tests := []struct {
+		line, want string
+	}{
+		{"//go:generate swag init --version {{Version}}", "//go:generate swag init --version v1"},
+		{"//go:build {{Version}}", "//go:build {{Version}}"},
+		{"//go:embed {{Version}}.json", "//go:embed {{Version}}.json"},
+		{"//go:linkname {{Version}} runtime.x", "//go:linkname {{Version}} runtime.x"},
+		{"//go:noinline {{Version}}", "//go:noinline {{Version}}"},
+		{"// go:build {{Version}}", "// go:build v1"},
+	}
+	for _, tt := range tests {
+		src := "package api\n\nconst Version = \"v1\"\n\n" + tt.line + "\nvar x int\n"
+		want := "package api\n\nconst Version = \"v1\"\n\n" + tt.want + "\nvar x int\n"
+		if got := process(t, NewSwaggerVariableReplacer(), src); got != want {
+			t.Errorf("%s: got %q, want %q", tt.line, got, want)
+		}
+	}
+}
+
+func TestBoolStrings(t *testing.T) {
+	src := "package api\n\nconst On = true\nconst Off = false\n\n// {{On}} {{Off}}\n"
+	if got := process(t, NewSwaggerVariableReplacer(), src); !strings.HasSuffix(got, "// true false\n") {
+		t.Errorf("default: %q", got)
+	}
+
+	r := NewSwaggerVariableReplacer()
+	r.SetBoolStrings("yes", "no")
+	if got := process(t, r, src); !strings.HasSuffix(got, "// yes no\n") {
+		t.Errorf("SetBoolStrings: %q", got)
+	}
+
+	r = NewSwaggerVariableReplacer()
+	if err := r.ApplyConfig(&Config{BoolTrue: "enabled", BoolFalse: "disabled"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := process(t, r, src); !strings.HasSuffix(got, "// enabled disabled\n") {
+		t.Errorf("config: %q", got)
+	}
+}
+
+func TestJSONExampleBody(t *testing.T) {
+	src := `package api
+
+const APIVersion = "v2"
+const MaxItems = 50
+const Debug = false
+
+// @Success 200 {object} Response "example:
+// {
+//   "version": "{{APIVersion}}",
+//   "limits": {"max": ${MaxItems}, "debug": @VAR(Debug)},
+//   "tags": ["{{APIVersion}}", "{{
+//   APIVersion}}"]
+// }"
+func Handler() {}
+`
+	want := `package api
+
+const APIVersion = "v2"
+const MaxItems = 50
+const Debug = false
+
+// @Success 200 {object} Response "example:
+// {
+//   "version": "v2",
+//   "limits": {"max": 50, "debug": false},
+//   "tags": ["v2", "v2
+// "]
+// }"
+func Handler() {}
+`
+	if got := process(t, NewSwaggerVariableReplacer(), src); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestValidator(t *testing.T) {
+	src := "package api\n\nconst StatusOK = 200\nconst StatusWeird = 999\n\n// @Success {{StatusOK}}\n// @Success {{StatusWeird}}\n"
+	var seen []Substitution
+	validator := func(sub Substitution) error {
+		seen = append(seen, sub)
+		if code, ok := sub.Value.(int); ok && code != 200 {
+			return fmt.Errorf("%d is not a valid status", code)
+		}
+		return nil
+	}
+
+	path := writeTestFile(t, t.TempDir(), "a.go", src)
+	r := NewSwaggerVariableReplacer()
+	r.SetValidator(validator)
+	stderr := capture(t, &os.Stderr, func() {
+		if _, err := r.ProcessFile(path); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(stderr, "999 is not a valid status") {
+		t.Errorf("no warning in %q", stderr)
+	}
+	if len(seen) != 2 || seen[1].Name != "StatusWeird" || seen[1].File != path || seen[1].Line != 7 {
+		t.Errorf("validator called with %+v", seen)
+	}
+
+	path = writeTestFile(t, t.TempDir(), "a.go", src)
+	r = NewSwaggerVariableReplacer()
+	r.SetValidator(validator)
+	r.SetStrict(true)
+	_, err := r.ProcessFile(path)
+	if err == nil || !strings.Contains(err.Error(), "999 is not a valid status") {
+		t.Errorf("strict err = %v", err)
+	}
+	if got := readTestFile(t, path); got != src {
+		t.Errorf("written despite the rejected value: %q", got)
+	}
+}
+
+func TestResolvedComments(t *testing.T) {
+	src := "package api\n\nconst A = 1\n\n// Package docs\n// @Param {{A}} {{Missing}}\nvar x = 1 // ${A}\n\n/* {{A}} */\n"
+	path := writeTestFile(t, t.TempDir(), "a.go", src)
+	var got map[int]string
+	capture(t, &os.Stderr, func() {
+		var err error
+		if got, err = NewSwaggerVariableReplacer().ResolvedComments(path); err != nil {
+			t.Fatal(err)
+		}
+	})
+	want := map[int]string{
+		5: "// Package docs",
+		6: "// @Param 1 {{Missing}}",
+		7: "// 1",
+		9: "/* 1 */",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ResolvedComments = %q, want %q", got, want)
+	}
+	if content := readTestFile(t, path); content != src {
+		t.Errorf("file written: %q", content)
+	}
+}
+
+func TestRecursiveResolve(t *testing.T) {
+	src := `package api
+
+const Inner = "in"
+const Template = "<{{Inner}}>"
+const Outer = "[{{Template}}]"
+const Loop = "({{Loop}})"
+
+// {{Template}} {{Outer}} {{Loop}}
+`
+	tests := []struct {
+		depth int
+		want  string
+	}{
+		{0, "// <{{Inner}}> [{{Template}}] ({{Loop}})\n"},
+		{1, "// <in> [<{{Inner}}>] ({{Loop}})\n"},
+		{5, "// <in> [<in>] ({{Loop}})\n"},
+	}
+	for _, tt := range tests {
+		r := NewSwaggerVariableReplacer()
+		r.SetRecursiveResolve(tt.depth)
+		var got string
+		stderr := capture(t, &os.Stderr, func() { got = process(t, r, src) })
+		if !strings.HasSuffix(got, tt.want) {
+			t.Errorf("depth %d: got %q, want it to end with %q", tt.depth, got, tt.want)
+		}
+		if cycle := strings.Contains(stderr, "cycle Loop -> Loop"); cycle != (tt.depth > 0) {
+			t.Errorf("depth %d: cycle warning %v in %q", tt.depth, cycle, stderr)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package replacer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Config is the JSON configuration file format
+type Config struct {
+	// Patterns are extra regexes, each with exactly one capture group for the variable name
+	Patterns []string `json:"patterns"`
+	// ExcludeFiles are glob patterns of files to skip in directory mode
+	ExcludeFiles []string `json:"exclude_files"`
+	// ConstantMap provides constants, substituted verbatim, for names not
+	// defined in source, or for every name with SetOverride
+	ConstantMap map[string]string `json:"constant_map"`
+	// FloatFormat is the fmt format for float values, e.g. "%.2f"
+	FloatFormat string `json:"float_format"`
+	// BoolTrue and BoolFalse are substituted for bool values instead of
+	// true and false, e.g. "enabled" and "disabled"
+	BoolTrue  string `json:"bool_true"`
+	BoolFalse string `json:"bool_false"`
+	// BackupSuffix replaces the .backup suffix of backup files, and
+	// BackupDir, if set, holds them instead of the directories of the files
+	BackupSuffix string `json:"backup_suffix"`
+	BackupDir    string `json:"backup_dir"`
+}
+
+// LoadConfig reads a JSON configuration file
+func LoadConfig(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %v", path, err)
+	}
+	return &cfg, nil
+}
+
+// ApplyConfig adds the configured patterns, exclusions and constant map to
+// the replacer and applies formatting options
+func (r *SwaggerVariableReplacer) ApplyConfig(cfg *Config) error {
+	for _, expr := range cfg.Patterns {
+		if err := r.AddPattern(expr); err != nil {
+			return err
+		}
+	}
+
+	for _, glob := range cfg.ExcludeFiles {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %v", glob, err)
+		}
+	}
+	r.excludes = append(r.excludes, cfg.ExcludeFiles...)
+
+	for name, value := range cfg.ConstantMap {
+		r.configConstants[name] = ConstantInfo{Value: value}
+	}
+
+	if cfg.FloatFormat != "" {
+		r.floatFormat = cfg.FloatFormat
+	}
+	if cfg.BoolTrue != "" {
+		r.boolTrue = cfg.BoolTrue
+	}
+	if cfg.BoolFalse != "" {
+		r.boolFalse = cfg.BoolFalse
+	}
+	if cfg.BackupSuffix != "" {
+		r.backupSuffix = cfg.BackupSuffix
+	}
+	if cfg.BackupDir != "" {
+		r.backupDir = cfg.BackupDir
+	}
+	return nil
+}
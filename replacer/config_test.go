@@ -0,0 +1,67 @@
+package replacer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyConfig(t *testing.T) {
+	path := writeTestFile(t, t.TempDir(), "config.json", `{
+	"patterns": ["\\[\\[(\\w+)\\]\\]"],
+	"constant_map": {"Host": "api.example.com"}
+}`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewSwaggerVariableReplacer()
+	if err := r.ApplyConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	src := "package api\n\nconst A = 1\n\n// [[A]] {{A}} [[Host]]\n"
+	want := "package api\n\nconst A = 1\n\n// 1 1 api.example.com\n"
+	if got := process(t, r, src); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadConfig(dir + "/missing.json"); err == nil {
+		t.Error("missing config loaded")
+	}
+	if _, err := LoadConfig(writeTestFile(t, dir, "bad.json", "{")); err == nil {
+		t.Error("malformed config loaded")
+	}
+	for _, cfg := range []*Config{
+		{Patterns: []string{"{{(\\w+"}},
+		{Patterns: []string{"{{\\w+}}"}},
+		{Patterns: []string{"{{(\\w+)(\\w+)}}"}},
+		{ExcludeFiles: []string{"[a-"}},
+	} {
+		if err := NewSwaggerVariableReplacer().ApplyConfig(cfg); err == nil {
+			t.Errorf("%+v applied without error", *cfg)
+		}
+	}
+}
+
+func TestConstantMapPrecedence(t *testing.T) {
+	src := "package api\n\nconst Host = \"localhost\"\n\n// {{Host}} {{Port}}\n"
+	cfg := &Config{ConstantMap: map[string]string{"Host": "api.example.com", "Port": "08080"}}
+	for _, tt := range []struct {
+		override bool
+		want     string
+	}{
+		{false, "// localhost 08080\n"},
+		{true, "// api.example.com 08080\n"},
+	} {
+		r := NewSwaggerVariableReplacer()
+		r.SetOverride(tt.override)
+		if err := r.ApplyConfig(cfg); err != nil {
+			t.Fatal(err)
+		}
+		if got := process(t, r, src); !strings.HasSuffix(got, tt.want) {
+			t.Errorf("override %v: got %q, want it to end with %q", tt.override, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package replacer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadData reads a flat JSON or YAML object of constant names to values,
+// as in {"APIVersion": "v2", "MaxItems": 100}, depending on the extension
+// of path. Numbers become int or float64 values like constants extracted
+// from source; values other than numbers, strings and bools are an error.
+func LoadData(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data: %v", err)
+	}
+
+	var data map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &data)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		err = decoder.Decode(&data)
+	default:
+		return nil, fmt.Errorf("unknown data format %s, expected .json, .yaml or .yml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse data %s: %v", path, err)
+	}
+
+	for name, value := range data {
+		switch v := value.(type) {
+		case json.Number:
+			if i, err := v.Int64(); err == nil && int64(int(i)) == i {
+				data[name] = int(i)
+			} else if f, err := v.Float64(); err == nil {
+				data[name] = f
+			} else {
+				return nil, fmt.Errorf("invalid number %s for %s in %s", v, name, path)
+			}
+		case string, int, float64, bool:
+		default:
+			return nil, fmt.Errorf("value of %s in %s is not a number, string or bool", name, path)
+		}
+	}
+	return data, nil
+}
+
+// AddData loads the constants of a JSON or YAML data file, see LoadData,
+// and adds them to the table; they can be referenced from every file, as
+// if defined in source
+func (r *SwaggerVariableReplacer) AddData(path string) error {
+	data, err := LoadData(path)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r.constants[name] = ConstantInfo{Value: data[name], File: path}
+	}
+	return nil
+}
+
+// SetNoSource skips extracting constants from the processed Go files, so
+// that only data files, packages and the config resolve placeholders, and
+// files that aren't valid Go, such as ones holding only comments, can be
+// processed
+func (r *SwaggerVariableReplacer) SetNoSource(enabled bool) {
+	r.noSource = enabled
+}
@@ -0,0 +1,67 @@
+package replacer
+
+import (
+	"testing"
+)
+
+func TestLoadData(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"values.json": `{"APIVersion": "v2", "MaxItems": 100, "Rate": 0.5, "Debug": true}`,
+		"values.yaml": "APIVersion: v2\nMaxItems: 100\nRate: 0.5\nDebug: true\n",
+	}
+	want := map[string]interface{}{"APIVersion": "v2", "MaxItems": 100, "Rate": 0.5, "Debug": true}
+	for name, content := range files {
+		data, err := LoadData(writeTestFile(t, dir, name, content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != len(want) {
+			t.Errorf("%s: got %v, want %v", name, data, want)
+		}
+		for key, value := range want {
+			if data[key] != value {
+				t.Errorf("%s: %s = %#v, want %#v", name, key, data[key], value)
+			}
+		}
+	}
+
+	for name, content := range map[string]string{
+		"nested.json": `{"A": {"B": 1}}`,
+		"list.yaml":   "A: [1, 2]\n",
+		"values.toml": "A = 1\n",
+		"bad.json":    "{",
+	} {
+		if _, err := LoadData(writeTestFile(t, dir, name, content)); err == nil {
+			t.Errorf("%s loaded without error", name)
+		}
+	}
+}
+
+func TestNoSource(t *testing.T) {
+	dir := t.TempDir()
+	data := writeTestFile(t, dir, "values.yaml", "APIVersion: v2\nMaxItems: 100\n")
+	path := writeTestFile(t, dir, "doc.go", "// Package api serves {{APIVersion}} with at most ${MaxItems} items\npackage api\n\nconst APIVersion = \"v1\"\n")
+
+	r := NewSwaggerVariableReplacer()
+	r.SetNoSource(true)
+	if err := r.AddData(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ProcessFile(path); err != nil {
+		t.Fatal(err)
+	}
+	want := "// Package api serves v2 with at most 100 items\npackage api\n\nconst APIVersion = \"v1\"\n"
+	if got := readTestFile(t, path); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Without parsing, files only need comments
+	comments := writeTestFile(t, dir, "comments.go", "// {{APIVersion}}\n/* ${MaxItems} */\n")
+	if _, err := r.ProcessFile(comments); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, comments); got != "// v2\n/* 100 */\n" {
+		t.Errorf("comment-only file: %q", got)
+	}
+}
@@ -0,0 +1,51 @@
+package replacer_test
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"gofmtcomment/replacer"
+)
+
+func ExampleSwaggerVariableReplacer_ProcessFile() {
+	dir, err := os.MkdirTemp("", "example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "handlers.go")
+	src := `package api
+
+const StatusOK = 200
+
+// @Success {{StatusOK}} {object} User
+func GetUser() {}
+`
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		log.Fatal(err)
+	}
+
+	r := replacer.NewSwaggerVariableReplacer()
+	res, err := r.ProcessFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(res.LinesChanged, "line(s) changed")
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(string(content))
+	// Output:
+	// 1 line(s) changed
+	// package api
+	//
+	// const StatusOK = 200
+	//
+	// // @Success 200 {object} User
+	// func GetUser() {}
+}
@@ -0,0 +1,646 @@
+package replacer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// AddPackage extracts the exported constants of the Go package in dir so
+// that comments can reference them qualified by the package name, as in
+// {{httpx.StatusTeapot}}. Subdirectories and test files are ignored.
+func (r *SwaggerVariableReplacer) AddPackage(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	pkg := NewSwaggerVariableReplacer()
+	pkgName := ""
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if pkgName == "" {
+			file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly)
+			if err != nil {
+				return err
+			}
+			pkgName = file.Name.Name
+		}
+		if err := pkg.extractConstants(path); err != nil {
+			return fmt.Errorf("failed to extract constants from %s: %v", path, err)
+		}
+	}
+	if pkgName == "" {
+		return fmt.Errorf("no Go files in %s", dir)
+	}
+
+	// Keep where each constant was defined for diagnostics
+	for name, info := range pkg.constants {
+		if token.IsExported(name) {
+			r.constants[pkgName+"."+name] = info
+		}
+	}
+	return nil
+}
+
+// AddPackageConstants registers constants to be referenced qualified by
+// pkg, as in {{pkg.Name}}
+func (r *SwaggerVariableReplacer) AddPackageConstants(pkg string, constants map[string]interface{}) {
+	for name, value := range constants {
+		r.constants[pkg+"."+name] = ConstantInfo{Value: value}
+	}
+}
+
+// extractConstants parses Go file and extracts constant declarations
+func (r *SwaggerVariableReplacer) extractConstants(filename string) error {
+	return r.extractConstantsFromSource(filename, nil)
+}
+
+// extractConstantsFromSource parses Go source and extracts constant
+// declarations; src is read from filename when nil
+func (r *SwaggerVariableReplacer) extractConstantsFromSource(filename string, src []byte) (err error) {
+	defer recoverFile(filename, &err)
+	if r.noSource {
+		return nil
+	}
+	// A nil []byte would be parsed as an empty file rather than read from disk
+	var source interface{}
+	if src != nil {
+		source = src
+	}
+
+	fset := token.NewFileSet()
+	node, err := parser.ParseFile(fset, filename, source, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	// Declarations referencing names declared later in the file are
+	// resolved in a second pass
+	type declaration struct {
+		name *ast.Ident
+		expr ast.Expr
+		iota int
+	}
+	var deferred []declaration
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recordImports(filename, node)
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.GenDecl:
+			if x.Tok == token.CONST {
+				// Specs without values repeat the previous expression list
+				// with the next iota, as in Go.
+				var values []ast.Expr
+				for iota, spec := range x.Specs {
+					if valueSpec, ok := spec.(*ast.ValueSpec); ok {
+						if len(valueSpec.Values) > 0 {
+							values = valueSpec.Values
+						}
+						for i, name := range valueSpec.Names {
+							if i < len(values) {
+								value := r.extractConstValue(values[i], iota)
+								if value != nil {
+									r.define(name.Name, value, literal(values[i]), fset.PositionFor(name.Pos(), false))
+									// fmt.Printf("Found constant: %s = %v\n", name.Name, value)
+								} else {
+									deferred = append(deferred, declaration{name, values[i], iota})
+								}
+							}
+						}
+					}
+				}
+				// Specs are fully handled here; don't revisit them below
+				return false
+			}
+		case *ast.FuncDecl:
+			r.extractStringer(x)
+		case *ast.ValueSpec:
+			// Handle variable declarations with an inferred type or an
+			// explicit named type such as `string` or `int`. Names
+			// assigned together from a single call, as in
+			// `var a, b = pair()`, have no value of their own.
+			if _, named := x.Type.(*ast.Ident); (x.Type == nil || named) && len(x.Values) == len(x.Names) {
+				for i, name := range x.Names {
+					if i < len(x.Values) {
+						value := r.extractConstValue(x.Values[i], -1)
+						if value != nil {
+							r.define(name.Name, value, literal(x.Values[i]), fset.PositionFor(name.Pos(), false))
+							// fmt.Printf("Found variable: %s = %v\n", name.Name, value)
+						} else if lit := compositeLit(x.Values[i]); lit != nil {
+							r.defineFields(name.Name, lit, fset)
+						} else {
+							deferred = append(deferred, declaration{name, x.Values[i], -1})
+						}
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	// Keep resolving until a pass makes no progress, so that chains of
+	// forward references resolve too
+	for progress := true; progress && len(deferred) > 0; {
+		progress = false
+		remaining := deferred[:0]
+		for _, d := range deferred {
+			if value := r.extractConstValue(d.expr, d.iota); value != nil {
+				r.define(d.name.Name, value, literal(d.expr), fset.PositionFor(d.name.Pos(), false))
+				progress = true
+			} else {
+				remaining = append(remaining, d)
+			}
+		}
+		deferred = remaining
+	}
+
+	// Whatever is left is unresolvable; definitions depending on each other
+	// in a cycle are worth naming, as no ordering will ever resolve them
+	deps := make(map[string][]string, len(deferred))
+	var names []string
+	for _, d := range deferred {
+		names = append(names, d.name.Name)
+		deps[d.name.Name] = nil
+	}
+	for _, d := range deferred {
+		for _, ref := range references(d.expr) {
+			if _, pending := deps[ref]; pending {
+				deps[d.name.Name] = append(deps[d.name.Name], ref)
+			}
+		}
+	}
+	for _, cycle := range findCycles(names, deps) {
+		description := strings.Join(cycle, " -> ")
+		if r.strict {
+			return fmt.Errorf("constant cycle: %s", description)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: Constant cycle %s left unresolved\n", description)
+	}
+
+	return nil
+}
+
+// references returns the identifiers expr refers to, leaving out the field
+// and method names of selectors
+func references(expr ast.Expr) []string {
+	var names []string
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.Ident:
+			names = append(names, x.Name)
+		case *ast.SelectorExpr:
+			ast.Inspect(x.X, visit)
+			return false
+		}
+		return true
+	}
+	ast.Inspect(expr, visit)
+	return names
+}
+
+// findCycles returns the reference cycles in deps, which maps each name to
+// the names its definition references, each as the path from its first name
+// in names order back to that name, as in [A B A]
+func findCycles(names []string, deps map[string][]string) [][]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(names))
+	var cycles [][]string
+	var path []string
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range deps[name] {
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+			case visiting:
+				for i, n := range path {
+					if n == dep {
+						cycle := append([]string{}, path[i:]...)
+						cycles = append(cycles, append(cycle, dep))
+						break
+					}
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+	}
+	for _, name := range names {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+	return cycles
+}
+
+// define records a constant extracted at pos, along with the numeric literal
+// it was declared as, if any. Positions aren't adjusted for //line
+// directives, so that constants are always attributed to the file they
+// were extracted from. Outside file scope, a different value already
+// defined in another file is reported as a conflict; the latest definition
+// wins in the shared table, while each file also keeps its own. The blank
+// identifier, and the fields or elements of a literal assigned to it, are
+// never recorded.
+func (r *SwaggerVariableReplacer) define(name string, value interface{}, raw string, pos token.Position) {
+	if name == "_" || strings.HasPrefix(name, "_.") {
+		return
+	}
+	info := ConstantInfo{Value: value, File: pos.Filename, Line: pos.Line, Raw: raw}
+	if previous, exists := r.constants[name]; exists {
+		if !r.fileScope && previous.File != "" && previous.File != info.File && previous.Value != value {
+			fmt.Fprintf(os.Stderr, "Warning: Constant '%s' is %v at %s but %v at %s\n", name, previous.Value, previous.Location(), value, info.Location())
+			r.conflicts = append(r.conflicts, Conflict{
+				Name:          name,
+				Value:         previous.Value,
+				Location:      previous.Location(),
+				OtherValue:    value,
+				OtherLocation: info.Location(),
+			})
+		}
+	}
+
+	r.constants[name] = info
+	if r.fileConstants[pos.Filename] == nil {
+		r.fileConstants[pos.Filename] = make(map[string]ConstantInfo)
+	}
+	r.fileConstants[pos.Filename][name] = info
+}
+
+// extractStringer records the names a String method returns for constants,
+// when it is a switch over the receiver with a string literal returned for
+// each case, as in:
+//
+//	func (s Status) String() string {
+//		switch s {
+//		case OK:
+//			return "OK"
+//		}
+//	}
+func (r *SwaggerVariableReplacer) extractStringer(fn *ast.FuncDecl) {
+	if fn.Name.Name != "String" || fn.Recv == nil || len(fn.Recv.List) != 1 || len(fn.Recv.List[0].Names) != 1 || fn.Body == nil {
+		return
+	}
+	receiver := fn.Recv.List[0].Names[0].Name
+
+	for _, stmt := range fn.Body.List {
+		sw, ok := stmt.(*ast.SwitchStmt)
+		if !ok {
+			continue
+		}
+		if tag, ok := sw.Tag.(*ast.Ident); !ok || tag.Name != receiver {
+			continue
+		}
+		for _, clause := range sw.Body.List {
+			cc, ok := clause.(*ast.CaseClause)
+			if !ok || len(cc.Body) != 1 {
+				continue
+			}
+			ret, ok := cc.Body[0].(*ast.ReturnStmt)
+			if !ok || len(ret.Results) != 1 {
+				continue
+			}
+			name, isStr := r.extractValue(ret.Results[0]).(string)
+			if !isStr {
+				continue
+			}
+			for _, expr := range cc.List {
+				if ident, ok := expr.(*ast.Ident); ok {
+					r.stringNames[ident.Name] = name
+				}
+			}
+		}
+	}
+}
+
+// defineFields records the elements of a map or struct literal, such as
+// map[string]int{"a": 1} or Config{Port: 80}, under dotted names like
+// prefix.a and prefix.Port. Nested literals are recorded recursively, and
+// elements whose key isn't a name or string are ignored. Slice and array
+// literals are recorded by index, as in prefix.0.
+func (r *SwaggerVariableReplacer) defineFields(prefix string, lit *ast.CompositeLit, fset *token.FileSet) {
+	if _, isList := lit.Type.(*ast.ArrayType); isList {
+		r.defineElements(prefix, lit, fset)
+		return
+	}
+	_, isMap := lit.Type.(*ast.MapType)
+
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+
+		var key string
+		switch k := kv.Key.(type) {
+		case *ast.BasicLit:
+			if k.Kind != token.STRING {
+				continue
+			}
+			unquoted, err := strconv.Unquote(k.Value)
+			if err != nil {
+				continue
+			}
+			key = unquoted
+		case *ast.Ident:
+			key = k.Name
+			// In a map, a name is a constant holding the key
+			if isMap {
+				str, isStr := r.extractConstValue(k, -1).(string)
+				if !isStr {
+					continue
+				}
+				key = str
+			}
+		default:
+			continue
+		}
+
+		name := prefix + "." + key
+		if value := r.extractConstValue(kv.Value, -1); value != nil {
+			r.define(name, value, literal(kv.Value), fset.PositionFor(kv.Key.Pos(), false))
+		} else if nested := compositeLit(kv.Value); nested != nil {
+			r.defineFields(name, nested, fset)
+		}
+	}
+}
+
+// defineElements records the elements of a slice or array literal, such as
+// []string{"/a", "/b"}, under indexed names like prefix.0 and prefix.1,
+// following explicit indexes as in [...]string{2: "c"}
+func (r *SwaggerVariableReplacer) defineElements(prefix string, lit *ast.CompositeLit, fset *token.FileSet) {
+	elem := lit.Type.(*ast.ArrayType).Elt
+	if star, ok := elem.(*ast.StarExpr); ok {
+		elem = star.X
+	}
+	index := 0
+	for _, elt := range lit.Elts {
+		value := elt
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			key, isInt := r.extractConstValue(kv.Key, -1).(int)
+			if !isInt || key < 0 {
+				return
+			}
+			index, value = key, kv.Value
+		}
+
+		name := prefix + "." + strconv.Itoa(index)
+		if v := r.extractConstValue(value, -1); v != nil {
+			r.define(name, v, literal(value), fset.PositionFor(value.Pos(), false))
+		} else if nested := compositeLit(value); nested != nil {
+			// Elements may leave out their type, as in [][]string{{"a"}}
+			if nested.Type == nil {
+				nested = &ast.CompositeLit{Type: elem, Elts: nested.Elts}
+			}
+			r.defineFields(name, nested, fset)
+		}
+		index++
+	}
+}
+
+// compositeLit returns expr if it is a composite literal, or the literal
+// it takes the address of, as in &Config{...}, or nil otherwise
+func compositeLit(expr ast.Expr) *ast.CompositeLit {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+	lit, _ := expr.(*ast.CompositeLit)
+	return lit
+}
+
+// literal returns the source text of expr if it is a numeric literal, with
+// its sign if it has one, as in -0.50, or "" otherwise. String literals
+// aren't kept: their decoded value already is the text as written.
+func literal(expr ast.Expr) string {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && (unary.Op == token.SUB || unary.Op == token.ADD) {
+		if text := literal(unary.X); text != "" && text[0] != '-' && text[0] != '+' {
+			return unary.Op.String() + text
+		}
+		return ""
+	}
+	if lit, ok := expr.(*ast.BasicLit); ok && (lit.Kind == token.INT || lit.Kind == token.FLOAT) {
+		return lit.Value
+	}
+	return ""
+}
+
+// extractConstValue evaluates a constant expression at the given iota
+// position (-1 outside a const block). Identifiers resolve from constants
+// already extracted; anything that can't be folded yields nil.
+func (r *SwaggerVariableReplacer) extractConstValue(expr ast.Expr, iota int) interface{} {
+	switch x := expr.(type) {
+	case *ast.Ident:
+		if x.Name == "iota" && iota >= 0 {
+			return iota
+		}
+		if value := r.extractValue(x); value != nil {
+			return value
+		}
+		if info, exists := r.configConstants[x.Name]; exists && r.override {
+			return info.Value
+		}
+		if info, exists := r.constants[x.Name]; exists {
+			return info.Value
+		}
+		if info, exists := r.configConstants[x.Name]; exists {
+			return info.Value
+		}
+		return nil
+	case *ast.ParenExpr:
+		return r.extractConstValue(x.X, iota)
+	case *ast.UnaryExpr:
+		value := r.extractConstValue(x.X, iota)
+		switch x.Op {
+		case token.ADD:
+			switch value.(type) {
+			case int, float64:
+				return value
+			}
+		case token.SUB:
+			switch v := value.(type) {
+			case int:
+				if v == math.MinInt {
+					return nil // -v overflows
+				}
+				return -v
+			case float64:
+				return -v
+			}
+		}
+		return nil
+	case *ast.BinaryExpr:
+		left := r.extractConstValue(x.X, iota)
+		right := r.extractConstValue(x.Y, iota)
+		switch l := left.(type) {
+		case int:
+			if rv, ok := right.(int); ok {
+				return foldInt(x.Op, l, rv)
+			}
+		case string:
+			if rv, ok := right.(string); ok && x.Op == token.ADD {
+				return l + rv
+			}
+		}
+		return nil
+	case *ast.CallExpr:
+		// Only conversions to predeclared types, as in int32(8080)
+		if fn, ok := x.Fun.(*ast.Ident); ok && len(x.Args) == 1 && !x.Ellipsis.IsValid() {
+			return convert(fn.Name, r.extractConstValue(x.Args[0], iota))
+		}
+		return nil
+	}
+	return r.extractValue(expr)
+}
+
+// convert applies a conversion to the predeclared type named typ, as Go
+// does for constants, returning nil if typ isn't such a type or value
+// can't be converted to it
+func convert(typ string, value interface{}) interface{} {
+	switch typ {
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte":
+		switch v := value.(type) {
+		case int:
+			return v
+		case rune:
+			return int(v)
+		case float64:
+			// Only floats with an exact integer value convert
+			if v == float64(int(v)) {
+				return int(v)
+			}
+		}
+	case "rune":
+		switch v := value.(type) {
+		case int:
+			return rune(v)
+		case rune:
+			return v
+		}
+	case "float32", "float64":
+		switch v := value.(type) {
+		case int:
+			return float64(v)
+		case rune:
+			return float64(v)
+		case float64:
+			return v
+		}
+	case "string":
+		switch v := value.(type) {
+		case string:
+			return v
+		case int:
+			return string(rune(v))
+		case rune:
+			return string(v)
+		}
+	case "bool":
+		if v, ok := value.(bool); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// foldInt applies an integer operator, returning nil for unsupported
+// operators, division by zero, negative shift counts and results that
+// overflow an int, which Go would compute exactly
+func foldInt(op token.Token, left, right int) interface{} {
+	x, y := constant.MakeInt64(int64(left)), constant.MakeInt64(int64(right))
+	var result constant.Value
+	switch op {
+	case token.ADD, token.SUB, token.MUL:
+		result = constant.BinaryOp(x, op, y)
+	case token.QUO, token.REM:
+		// go/constant divides small values as int64s, which overflow here
+		if right == 0 || (right == -1 && int64(left) == math.MinInt64) {
+			return nil
+		}
+		// QUO_ASSIGN asks go/constant for integer division
+		if op == token.QUO {
+			op = token.QUO_ASSIGN
+		}
+		result = constant.BinaryOp(x, op, y)
+	case token.SHL, token.SHR:
+		if right < 0 {
+			return nil
+		}
+		// Shifting further changes nothing, but would take memory
+		result = constant.Shift(x, op, uint(min(right, 64)))
+	default:
+		return nil
+	}
+	value, exact := constant.Int64Val(result)
+	if !exact || int64(int(value)) != value {
+		return nil
+	}
+	return int(value)
+}
+
+// extractValue extracts literal values from AST expressions
+func (r *SwaggerVariableReplacer) extractValue(expr ast.Expr) interface{} {
+	switch x := expr.(type) {
+	case *ast.BasicLit:
+		// Literals of hand-built or broken syntax trees may lack quotes
+		if (x.Kind == token.STRING || x.Kind == token.CHAR) && len(x.Value) < 2 {
+			return nil
+		}
+		switch x.Kind {
+		case token.INT:
+			// Base 0 accepts every Go integer literal: 0x, 0o, 0b and 1_000
+			if val, err := strconv.ParseInt(x.Value, 0, strconv.IntSize); err == nil {
+				return int(val)
+			}
+		case token.STRING:
+			// Decode escapes as Go does; raw strings only drop carriage returns
+			str, err := strconv.Unquote(x.Value)
+			if err != nil {
+				return nil
+			}
+			if x.Value[0] == '`' {
+				str = strings.ReplaceAll(str, "\r", "")
+			}
+			return str
+		case token.FLOAT:
+			if val, err := strconv.ParseFloat(x.Value, 64); err == nil {
+				return val
+			}
+		case token.CHAR:
+			// Kept as a rune so it substitutes as a character, not an int
+			val, _, tail, err := strconv.UnquoteChar(x.Value[1:len(x.Value)-1], '\'')
+			if err == nil && tail == "" {
+				return val
+			}
+		}
+	case *ast.Ident:
+		// Handle boolean literals
+		switch x.Name {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+	}
+	return nil
+}
@@ -0,0 +1,471 @@
+package replacer
+
+import (
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// extracted extracts the constants of src and returns the value of name
+func extracted(t *testing.T, src, name string) (interface{}, bool) {
+	t.Helper()
+	r := NewSwaggerVariableReplacer()
+	if err := r.ExtractConstantsFromSource([]byte(src)); err != nil {
+		t.Fatal(err)
+	}
+	info, ok := r.constants[name]
+	return info.Value, ok
+}
+
+func TestConstantArithmetic(t *testing.T) {
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"Base + 2", 12},
+		{"Base - 12", -2},
+		{"Base * 3", 30},
+		{"Base / 3", 3},
+		{"Base % 3", 1},
+		{"1 << 4", 16},
+		{"Base >> 1", 5},
+		{"(Base + 2) * 2", 24},
+		{"-Base", -10},
+		{"1.5 * 2", nil},
+		{"Base / 0", nil},
+		{"Base % 0", nil},
+		{"1 << -1", nil},
+		{"1 << 100", nil},
+		{"Base * 1_000_000_000 * 1_000_000_000 * 1_000_000_000", nil},
+		{"-9223372036854775807 - 1 - 1", nil},
+		{"(-9223372036854775807 - 1) / -1", nil},
+		{"-1 >> 200", -1},
+		{"-(-9223372036854775807 - 1)", nil},
+	}
+	for _, tt := range tests {
+		src := "package api\n\nconst Base = 10\n\nconst X = " + tt.expr + "\n"
+		got, ok := extracted(t, src, "X")
+		if tt.want == nil {
+			if ok && got != nil {
+				t.Errorf("%s = %v, want unresolved", tt.expr, got)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestConstantOverflowLeftUnresolved(t *testing.T) {
+	src := "package api\n\nconst Big = 1 << 100\nconst Small = Big >> 98\n\n// {{Small}}\n"
+	if got := process(t, NewSwaggerVariableReplacer(), src); got != src {
+		t.Errorf("overflowing constant substituted:\n%s", got)
+	}
+}
+
+func TestTrailingSpecComments(t *testing.T) {
+	r := NewSwaggerVariableReplacer()
+	if err := r.ExtractFromFile("testdata/trailing.go"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"StatusSuccess":      200,
+		"StatusCreated":      201,
+		"StatusBadRequest":   400,
+		"StatusUnauthorized": 401,
+		"StatusNotFound":     404,
+		"StatusServerError":  500,
+		"MessageSuccess":     "Operation completed successfully",
+		"MessageNotFound":    "Resource not found",
+	}
+	for name, value := range want {
+		if got := r.constants[name].Value; got != value {
+			t.Errorf("%s = %v, want %v", name, got, value)
+		}
+	}
+}
+
+func TestIotaBlock(t *testing.T) {
+	src := `package api
+
+const (
+	StatusPending = iota
+	StatusActive
+	StatusClosed
+)
+
+const (
+	_ = iota
+	KB = 1 << (10 * iota)
+	MB
+)
+
+const (
+	First = iota + 1
+	Second
+)
+
+// @Param status query int false "{{StatusPending}} {{StatusActive}} {{StatusClosed}}"
+`
+	want := map[string]interface{}{
+		"StatusPending": 0, "StatusActive": 1, "StatusClosed": 2,
+		"KB": 1024, "MB": 1048576,
+		"First": 1, "Second": 2,
+	}
+	for name, value := range want {
+		if got, _ := extracted(t, src, name); got != value {
+			t.Errorf("%s = %v, want %v", name, got, value)
+		}
+	}
+	got := process(t, NewSwaggerVariableReplacer(), src)
+	if !strings.Contains(got, `"0 1 2"`) {
+		t.Errorf("iota constants not substituted:\n%s", got)
+	}
+}
+
+func TestTypedDeclarations(t *testing.T) {
+	tests := []struct {
+		typ, value string
+		want       interface{}
+	}{
+		{"int", "200", 200},
+		{"string", `"ok"`, "ok"},
+		{"float64", "1.5", 1.5},
+		{"bool", "true", true},
+	}
+	for _, tt := range tests {
+		for _, decl := range []string{"const", "var"} {
+			typed, _ := extracted(t, "package api\n\n"+decl+" X "+tt.typ+" = "+tt.value+"\n", "X")
+			untyped, _ := extracted(t, "package api\n\n"+decl+" X = "+tt.value+"\n", "X")
+			if typed != tt.want || untyped != tt.want {
+				t.Errorf("%s X %s = %s: typed %v, untyped %v, want %v", decl, tt.typ, tt.value, typed, untyped, tt.want)
+			}
+		}
+	}
+}
+
+func TestStringConcatenation(t *testing.T) {
+	src := `package api
+
+const AppName = "shop"
+const Version = "v2"
+const Greeting = "Hello, " + AppName + "!"
+const Banner = AppName + " " + Version
+const Broken = AppName + Unknown
+const Mixed = AppName + 1
+`
+	want := map[string]interface{}{
+		"Greeting": "Hello, shop!",
+		"Banner":   "shop v2",
+		"Broken":   nil,
+		"Mixed":    nil,
+	}
+	for name, value := range want {
+		if got, _ := extracted(t, src, name); got != value {
+			t.Errorf("%s = %v, want %v", name, got, value)
+		}
+	}
+}
+
+func TestQualifiedReferences(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "b/b.go", "package b\n\nconst StatusTeapot = 418\nconst unexported = 1\n")
+	a := writeTestFile(t, dir, "a/a.go", "package a\n\n// @Failure {{b.StatusTeapot}} {{b.unexported}} {{c.Other}}\n// ${httpx.Code}\n")
+
+	r := NewSwaggerVariableReplacer()
+	if err := r.AddPackage(dir + "/b"); err != nil {
+		t.Fatal(err)
+	}
+	r.AddPackageConstants("httpx", map[string]interface{}{"Code": 201})
+	var res *Result
+	stderr := capture(t, &os.Stderr, func() {
+		var err error
+		if res, err = r.ProcessFile(a); err != nil {
+			t.Fatal(err)
+		}
+	})
+	want := "package a\n\n// @Failure 418 {{b.unexported}} {{c.Other}}\n// 201\n"
+	if got := readTestFile(t, a); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if len(res.Unresolved) != 2 || !strings.Contains(stderr, "Warning: Variable 'c.Other' not found") {
+		t.Errorf("Unresolved = %v, stderr %q", res.Unresolved, stderr)
+	}
+}
+
+func TestStringLiteralUnquoting(t *testing.T) {
+	tests := []struct {
+		literal string
+		want    string
+	}{
+		{`"\"hello\""`, `"hello"`},
+		{`"say \"hi\""`, `say "hi"`},
+		{`"a\nb"`, "a\nb"},
+		{`"a\tb"`, "a\tb"},
+		{`"caf\u00e9 \U0001F600"`, "café 😀"},
+		{`"\x41\101"`, "AA"},
+		{"`raw \\n \"quoted\"`", `raw \n "quoted"`},
+		{"`multi\nline`", "multi\nline"},
+	}
+	for _, tt := range tests {
+		got, _ := extracted(t, "package api\n\nconst S = "+tt.literal+"\n", "S")
+		if got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.literal, got, tt.want)
+		}
+	}
+}
+
+func TestIntegerLiterals(t *testing.T) {
+	tests := []struct {
+		literal string
+		want    interface{}
+	}{
+		{"0xFF", 255},
+		{"0X1f", 31},
+		{"0o17", 15},
+		{"017", 15},
+		{"0b1010", 10},
+		{"1_000_000", 1000000},
+		{"-42", -42},
+		{"-0x10", -16},
+		{"+7", 7},
+	}
+	for _, tt := range tests {
+		if got, _ := extracted(t, "package api\n\nconst N = "+tt.literal+"\n", "N"); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.literal, got, tt.want)
+		}
+	}
+}
+
+func TestConstantLocation(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "consts.go", "package api\n\nconst (\n\tA = 1\n\tB = \"b\"\n)\n\nvar C = 0x10\n")
+	b := writeTestFile(t, dir, "b.go", "package api\n\n// {{B}}\n")
+	r := NewSwaggerVariableReplacer()
+	if err := r.ExtractFromDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	consts := filepath.Join(dir, "consts.go")
+	for name, want := range map[string]ConstantInfo{
+		"A": {Value: 1, File: consts, Line: 4, Raw: "1"},
+		"B": {Value: "b", File: consts, Line: 5},
+		"C": {Value: 16, File: consts, Line: 8, Raw: "0x10"},
+	} {
+		if got := r.constants[name]; got != want {
+			t.Errorf("%s = %+v, want %+v", name, got, want)
+		}
+	}
+
+	res, err := r.ReplaceInFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Substitutions) != 1 || res.Substitutions[0].Definition != consts+":5" {
+		t.Errorf("Substitutions = %+v, want one defined at %s:5", res.Substitutions, consts)
+	}
+}
+
+func TestCompositeLiterals(t *testing.T) {
+	src := `package api
+
+type Limits struct {
+	Max  int
+	Name string
+}
+
+var Defaults = map[string]int{"a": 1, "b": 2}
+
+var Limit = Limits{Max: 10, Name: "default"}
+
+// {{Defaults.a}} {{Defaults.b}} {{Limit.Max}} {{Limit.Name}} {{Defaults.c}}
+`
+	var got string
+	stderr := capture(t, &os.Stderr, func() { got = process(t, NewSwaggerVariableReplacer(), src) })
+	if want := "// 1 2 10 default {{Defaults.c}}\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("got %q, want it to end with %q", got, want)
+	}
+	if !strings.Contains(stderr, "'Defaults.c' not found") {
+		t.Errorf("no warning for Defaults.c in %q", stderr)
+	}
+}
+
+func TestTypedConstStringer(t *testing.T) {
+	src := `package api
+
+type Status int
+
+const (
+	OK       Status = 200
+	NotFound Status = 404
+	Teapot   Status = 418
+)
+
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "OK"
+	case NotFound:
+		return "Not Found"
+	}
+	return "unknown"
+}
+
+// {{OK}} {{NotFound}} {{Teapot}}
+`
+	for name, want := range map[string]int{"OK": 200, "NotFound": 404, "Teapot": 418} {
+		if got, _ := extracted(t, src, name); got != want {
+			t.Errorf("%s = %v, want %d", name, got, want)
+		}
+	}
+	if got := process(t, NewSwaggerVariableReplacer(), src); !strings.HasSuffix(got, "// 200 404 418\n") {
+		t.Errorf("numeric values not substituted: %q", got)
+	}
+	r := NewSwaggerVariableReplacer()
+	r.SetStringer(true)
+	if got := process(t, r, src); !strings.HasSuffix(got, "// OK Not Found 418\n") {
+		t.Errorf("String values not substituted: %q", got)
+	}
+}
+
+func TestForwardReferences(t *testing.T) {
+	src := `package api
+
+const A = B + 1
+const Greeting = "Hello, " + Name
+const C = A * 2
+
+const B = 2
+const Name = "world"
+`
+	for name, want := range map[string]interface{}{"A": 3, "C": 6, "Greeting": "Hello, world"} {
+		if got, _ := extracted(t, src, name); got != want {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+	if got, _ := extracted(t, "package api\n\nconst X = Y + 1\nconst Y = X + 1\n", "X"); got != nil {
+		t.Errorf("cyclic X = %v, want unresolved", got)
+	}
+}
+
+func TestConstantCycles(t *testing.T) {
+	src := "package api\n\nconst A = B\nconst B = A\nconst Self = Self + 1\nconst OK = 1\n\n// {{A}} {{Self}} {{OK}}\n"
+
+	var got string
+	stderr := capture(t, &os.Stderr, func() { got = process(t, NewSwaggerVariableReplacer(), src) })
+	if !strings.HasSuffix(got, "// {{A}} {{Self}} 1\n") {
+		t.Errorf("got %q", got)
+	}
+	for _, cycle := range []string{"A -> B -> A", "Self -> Self"} {
+		if !strings.Contains(stderr, "Constant cycle "+cycle) {
+			t.Errorf("cycle %s not reported: %q", cycle, stderr)
+		}
+	}
+
+	r := NewSwaggerVariableReplacer()
+	r.SetStrict(true)
+	if _, err := r.ProcessSource(nil, []byte(src)); err == nil || !strings.Contains(err.Error(), "constant cycle: A -> B -> A") {
+		t.Errorf("strict mode err = %v", err)
+	}
+}
+
+func TestDegenerateLiterals(t *testing.T) {
+	r := NewSwaggerVariableReplacer()
+	for _, lit := range []*ast.BasicLit{
+		{Kind: token.STRING, Value: ""},
+		{Kind: token.STRING, Value: `"`},
+		{Kind: token.STRING, Value: "`"},
+		{Kind: token.CHAR, Value: ""},
+		{Kind: token.CHAR, Value: "''"},
+		{Kind: token.INT, Value: ""},
+		{Kind: token.FLOAT, Value: ""},
+	} {
+		if got := r.extractValue(lit); got != nil {
+			t.Errorf("%s literal %q = %v, want unresolved", lit.Kind, lit.Value, got)
+		}
+	}
+}
+
+func TestPanicRecovered(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n\n// {{A}}\n")
+	writeTestFile(t, dir, "b.go", "package api\n\n// nothing\n")
+	r := NewSwaggerVariableReplacer()
+	r.SetValidator(func(sub Substitution) error { panic("pathological") })
+	var err error
+	stderr := capture(t, &os.Stderr, func() { err = r.ProcessDirectory(dir) })
+	if err == nil || !strings.Contains(stderr+err.Error(), "a.go") || !strings.Contains(stderr+err.Error(), "pathological") {
+		t.Errorf("panic not turned into an error naming the file: %v, %q", err, stderr)
+	}
+}
+
+func TestMultiNameSpecs(t *testing.T) {
+	src := `package api
+
+const A, B = 1, 2
+
+var C, D = "c", true
+
+var E, F string = "e", "f"
+
+const (
+	G, H = iota, iota * 10
+	I, J
+)
+`
+	for name, want := range map[string]interface{}{
+		"A": 1, "B": 2, "C": "c", "D": true, "E": "e", "F": "f",
+		"G": 0, "H": 0, "I": 1, "J": 10,
+	} {
+		if got, _ := extracted(t, src, name); got != want {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestConversions(t *testing.T) {
+	src := `package api
+
+const Port = int32(8080)
+const Name = string("svc")
+const Rate = float64(2)
+const Ratio = float32(0.5)
+const Neg = int64(-3)
+const Sum = uint8(1) + 2
+
+func compute() int { return 1 }
+
+var Computed = compute()
+var Length = len("abc")
+`
+	for name, want := range map[string]interface{}{
+		"Port": 8080, "Name": "svc", "Rate": 2.0, "Ratio": 0.5, "Neg": -3, "Sum": 3,
+		"Computed": nil, "Length": nil,
+	} {
+		if got, _ := extracted(t, src, name); got != want {
+			t.Errorf("%s = %#v, want %#v", name, got, want)
+		}
+	}
+}
+
+func TestSliceIndexes(t *testing.T) {
+	src := `package api
+
+var Routes = []string{"/a", "/b"}
+
+var Ports = [2]int{80, 443}
+
+// {{Routes.0}} {{Routes.1}} {{Ports.1}} {{Routes.2}}
+`
+	var got string
+	stderr := capture(t, &os.Stderr, func() { got = process(t, NewSwaggerVariableReplacer(), src) })
+	if want := "// /a /b 443 {{Routes.2}}\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("got %q, want it to end with %q", got, want)
+	}
+	if !strings.Contains(stderr, "Index 2 of 'Routes' out of range") {
+		t.Errorf("no out of range warning in %q", stderr)
+	}
+}
@@ -0,0 +1,463 @@
+package replacer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// lineChange records a single line rewritten by substitution
+type lineChange struct {
+	line    int // 1-based line number in the original file
+	oldText string
+	newText string
+}
+
+// replaceVariablesInComments reads file, replaces variables in comments, and writes back
+func (r *SwaggerVariableReplacer) replaceVariablesInComments(filename string) (_ *Result, err error) {
+	defer recoverFile(filename, &err)
+	if r.dryRun || r.check {
+		res, err := r.previewFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		r.record(res)
+		return res, nil
+	}
+
+	newContent, res, err := r.substituteFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range res.changes {
+		r.logf("Replaced: %s\n", change.oldText)
+		r.logf("    With: %s\n", change.newText)
+	}
+	for _, sub := range res.Substitutions {
+		if sub.Definition != "" {
+			r.logf("%s:%d: %s defined at %s\n", filename, sub.Line, sub.Name, sub.Definition)
+		}
+	}
+
+	// Write back if modified, keeping a copy of the original first
+	if res.LinesChanged > 0 {
+		if newContent, err = r.gofmtSource(filename, newContent); err != nil {
+			return nil, err
+		}
+		if err := r.checkWritable(filename); err != nil {
+			return nil, err
+		}
+		if r.backup {
+			if err := r.BackupFile(filename); err != nil {
+				return nil, fmt.Errorf("failed to back up %s: %v", filename, err)
+			}
+		}
+		if err := writeFile(filename, []byte(newContent)); err != nil {
+			if os.IsPermission(err) {
+				return nil, r.readOnlyError(filename)
+			}
+			return nil, err
+		}
+	}
+
+	r.record(res)
+	return res, nil
+}
+
+// checkWritable returns an error matching ErrReadOnly if filename is
+// read-only, unless SetWriteReadOnly is on
+func (r *SwaggerVariableReplacer) checkWritable(filename string) error {
+	info, err := os.Stat(filename)
+	if err != nil || info.Mode().Perm()&0200 != 0 || r.writeReadOnly {
+		return nil
+	}
+	return r.readOnlyError(filename)
+}
+
+// readOnlyError records filename as skipped for being read-only, to be
+// summarized at the end of the run, and returns the error reporting it
+func (r *SwaggerVariableReplacer) readOnlyError(filename string) error {
+	r.mu.Lock()
+	r.readOnly = append(r.readOnly, filename)
+	r.mu.Unlock()
+	return &checkError{ErrReadOnly, fmt.Sprintf("%s is read-only", filename)}
+}
+
+// invalidError returns the substitutions of res the validator rejected as
+// an error in strict mode, or nil
+func (r *SwaggerVariableReplacer) invalidError(res *Result) error {
+	if !r.strict {
+		return nil
+	}
+	return errors.Join(res.invalid...)
+}
+
+// gofmtSource formats substituted source with go/format when SetGofmt is
+// on, keeping any byte order mark, and returns it unchanged otherwise. As
+// go/format writes LF line endings, source using CRLF gets CRLF back on
+// every line.
+func (r *SwaggerVariableReplacer) gofmtSource(filename, content string) (string, error) {
+	if !r.gofmt {
+		return content, nil
+	}
+	src, bom := bytes.CutPrefix([]byte(content), utf8BOM)
+	formatted, err := format.Source(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to gofmt %s after substitution: %v", filename, err)
+	}
+	if bytes.Contains(src, []byte("\r\n")) {
+		formatted = bytes.ReplaceAll(formatted, []byte("\n"), []byte("\r\n"))
+	}
+	if bom {
+		return string(utf8BOM) + string(formatted), nil
+	}
+	return string(formatted), nil
+}
+
+// recoverFile turns a panic while processing filename into an error set in
+// *err, so that one pathological file can't crash a whole run. It must be
+// deferred directly.
+func recoverFile(filename string, err *error) {
+	if p := recover(); p != nil {
+		*err = fmt.Errorf("internal error processing %s: %v", filename, p)
+	}
+}
+
+// BackupFile copies filename to its backup path with the same permissions,
+// replacing any previous backup; see SetBackupSuffix and SetBackupDir
+func (r *SwaggerVariableReplacer) BackupFile(filename string) error {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	backupName := r.backupPath(filename)
+	if err := os.MkdirAll(filepath.Dir(backupName), 0755); err != nil {
+		return err
+	}
+	// A new file gets the permissions, where an old backup would keep its own
+	if err := os.Remove(backupName); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return os.WriteFile(backupName, content, info.Mode().Perm())
+}
+
+// backupPath returns where filename is backed up: next to it, or under the
+// backup directory at the same path relative to the root, see SetRoot, or
+// the working directory, or at its absolute path for files outside it
+func (r *SwaggerVariableReplacer) backupPath(filename string) string {
+	name := filename + r.backupSuffix
+	if r.backupDir == "" {
+		return name
+	}
+	rel := filepath.Clean(filepath.FromSlash(r.displayPath(name)))
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		if abs, err := filepath.Abs(rel); err == nil {
+			rel = strings.TrimPrefix(abs, filepath.VolumeName(abs))
+		}
+	}
+	return filepath.Join(r.backupDir, rel)
+}
+
+// rename is os.Rename, replaced in tests to simulate failed writes
+var rename = os.Rename
+
+// writeFile atomically replaces filename with data by writing a temporary
+// file in the same directory and renaming it over the original. The
+// permission bits of the existing file are kept, falling back to 0644 when
+// they can't be read, and a symlink is kept by writing to its target.
+func writeFile(filename string, data []byte) (err error) {
+	if real, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = real
+	}
+	mode := os.FileMode(0644)
+	if info, statErr := os.Stat(filename); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return rename(tmp.Name(), filename)
+}
+
+// substituteFile reads file and returns its content with variables in
+// comments replaced, along with the result of the substitution
+func (r *SwaggerVariableReplacer) substituteFile(filename string) (string, *Result, error) {
+	// Read file
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return "", nil, err
+	}
+
+	newContent, res := r.substituteSource(filename, content)
+	if err := r.invalidError(res); err != nil {
+		return "", nil, err
+	}
+	return newContent, res, nil
+}
+
+// substituteSource returns content with variables in comments replaced,
+// along with the result of the substitution; filename is used for diagnostics.
+// Content is split and rejoined on "\n" alone, so everything outside the
+// substituted comments, including whether the file ends with a newline and
+// CRLF line endings and a UTF-8 byte order mark, is kept byte for byte.
+func (r *SwaggerVariableReplacer) substituteSource(filename string, content []byte) (string, *Result) {
+	// Set a byte order mark aside so the first line starts as written
+	content, bom := bytes.CutPrefix(content, utf8BOM)
+	original := strings.Split(string(content), "\n")
+	lines := make([]string, len(original))
+	res := &Result{File: filename}
+	var scanner commentScanner
+
+	// Set CRLF endings aside while substituting so that comments never
+	// include the \r, then end every line of the result with one again
+	crlf := make([]bool, len(original))
+	for i, line := range original {
+		lines[i], crlf[i] = strings.CutSuffix(line, "\r")
+	}
+
+	// Process the comment portion of each line, as located by the parser
+	// or else by scanning lines
+	var parsed [][]commentSpan
+	if r.structFields {
+		if parsed, _ = fieldCommentSpans(filename, content, lines); parsed == nil {
+			parsed = make([][]commentSpan, len(lines))
+		}
+	} else if r.astComments {
+		parsed, _ = astCommentSpans(filename, content, lines)
+	}
+	// String literals are located by the parser too, alongside comments
+	if r.inStrings && !r.structFields {
+		if parsed == nil {
+			parsed = make([][]commentSpan, len(lines))
+			for i, line := range lines {
+				parsed[i] = scanner.commentSpans(line)
+			}
+		}
+		addStringSpans(parsed, filename, content, lines)
+	}
+	mark := r.markUnresolved && !r.strict && r.reverse == nil
+	for i, line := range lines {
+		var spans []commentSpan
+		if parsed != nil {
+			spans = parsed[i]
+		} else {
+			spans = scanner.commentSpans(line)
+		}
+		// A marker from a previous run is added again below if still due
+		if mark {
+			line = r.cutUnresolvedMarker(line, spans)
+			spans = clampSpans(spans, len(line))
+		}
+		if len(spans) > 0 && i+1 < len(lines) && r.reverse == nil {
+			last := &spans[len(spans)-1]
+			if joined, next, ok := r.joinSplitPlaceholder(res.File, line, *last, lines[i+1]); ok {
+				line, lines[i+1] = joined, next
+				last.end = len(line)
+				if parsed != nil && len(parsed[i+1]) > 0 {
+					parsed[i+1][0].end = len(next)
+				}
+			}
+		}
+		from := len(res.missing)
+		if len(spans) > 0 {
+			line = r.processCommentSpans(line, spans, i+1, res)
+		}
+		if mark && len(res.missing) > from && spans[len(spans)-1].quote == 0 {
+			line = markUnresolved(line, res.missing[from:])
+		}
+		lines[i] = line
+	}
+	if r.align {
+		stripped := make([]string, len(original))
+		for i, line := range original {
+			stripped[i] = strings.TrimSuffix(line, "\r")
+		}
+		alignComments(lines, stripped)
+	}
+	for i := range lines {
+		if crlf[i] {
+			lines[i] = strings.ReplaceAll(lines[i], "\n", "\r\n") + "\r"
+		}
+	}
+
+	for i, line := range lines {
+		if line != original[i] {
+			res.changes = append(res.changes, lineChange{line: i + 1, oldText: original[i], newText: line})
+		}
+	}
+	res.LinesChanged = len(res.changes)
+
+	r.mu.Lock()
+	r.unresolved = append(r.unresolved, res.missing...)
+	r.malformed = append(r.malformed, res.malformed...)
+	r.mu.Unlock()
+
+	if bom {
+		return string(utf8BOM) + strings.Join(lines, "\n"), res
+	}
+	return strings.Join(lines, "\n"), res
+}
+
+// unresolvedMarker starts the comment SetMarkUnresolved appends to lines
+// with unresolved placeholders, followed by their names
+const unresolvedMarker = " // TODO: unresolved "
+
+// cutUnresolvedMarker returns line without the marker markUnresolved
+// appended to it, if the last of its comment spans runs to the end of the
+// line and ends with exactly such a marker. Any other text, such as a
+// TODO comment written by hand or one inside a string, is kept.
+func (r *SwaggerVariableReplacer) cutUnresolvedMarker(line string, spans []commentSpan) string {
+	if len(spans) == 0 {
+		return line
+	}
+	last := spans[len(spans)-1]
+	i := strings.LastIndex(line, unresolvedMarker)
+	// The slashes of the marker must be inside the comment
+	if last.quote != 0 || last.end != len(line) || i < 0 || i+1 < last.start {
+		return line
+	}
+	rest := line[i+len(unresolvedMarker):]
+	var placeholders []string
+	for _, p := range r.findPlaceholders(rest) {
+		placeholders = append(placeholders, rest[p.start:p.end])
+	}
+	if len(placeholders) == 0 || strings.Join(placeholders, ", ") != rest {
+		return line
+	}
+	return line[:i]
+}
+
+// markUnresolved appends the unresolved marker listing each placeholder of
+// missing once, as written, to line
+func markUnresolved(line string, missing []unresolvedVar) string {
+	var placeholders []string
+	seen := make(map[string]bool)
+	for _, u := range missing {
+		if !seen[u.placeholder] {
+			seen[u.placeholder] = true
+			placeholders = append(placeholders, u.placeholder)
+		}
+	}
+	return line + unresolvedMarker + strings.Join(placeholders, ", ")
+}
+
+// clampSpans returns spans cut to a line shortened to length, dropping
+// those past its end
+func clampSpans(spans []commentSpan, length int) []commentSpan {
+	var clamped []commentSpan
+	for _, span := range spans {
+		if span.start < length {
+			span.end = min(span.end, length)
+			clamped = append(clamped, span)
+		}
+	}
+	return clamped
+}
+
+// utf8BOM is the byte order mark some editors start UTF-8 files with
+var utf8BOM = []byte("\ufeff")
+
+// previewFile computes the pending changes of a file without writing,
+// printing them as a unified diff, or a summary with SetSummary, unless in
+// check mode. While files are processed concurrently, the output is kept in
+// the result instead, to be printed in walk order.
+func (r *SwaggerVariableReplacer) previewFile(filename string) (*Result, error) {
+	_, res, err := r.substituteFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if res.LinesChanged > 0 && !r.check {
+		var b strings.Builder
+		if r.summary {
+			writeSummary(&b, r.displayPath(filename), res.changes, r.color, r.verbose)
+		} else {
+			writeUnifiedDiff(&b, r.displayPath(filename), res.changes, r.color)
+		}
+		if r.holdPreviews {
+			res.preview = b.String()
+		} else {
+			fmt.Print(b.String())
+		}
+	}
+	return res, nil
+}
+
+// ANSI escape sequences coloring removed and added lines
+const (
+	colorRemoved = "\x1b[31m"
+	colorAdded   = "\x1b[32m"
+	colorReset   = "\x1b[0m"
+)
+
+// paint wraps text in the given color when color is on
+func paint(text, ansi string, color bool) string {
+	if !color {
+		return text
+	}
+	return ansi + text + colorReset
+}
+
+// writeSummary writes the file name followed by each changed line as
+// "before -> after", prefixed by its line number when verbose, with
+// indentation trimmed and, with color, before in red and after in green
+func writeSummary(w io.Writer, filename string, changes []lineChange, color, verbose bool) {
+	fmt.Fprintln(w, filename)
+	for _, change := range changes {
+		before := paint(strings.TrimSpace(change.oldText), colorRemoved, color)
+		after := paint(strings.TrimSpace(change.newText), colorAdded, color)
+		if verbose {
+			fmt.Fprintf(w, "  %d: %s -> %s\n", change.line, before, after)
+		} else {
+			fmt.Fprintf(w, "  %s -> %s\n", before, after)
+		}
+	}
+}
+
+// writeUnifiedDiff writes changes as a unified diff with one hunk per
+// changed line, with removed lines in red and added lines in green with
+// color
+func writeUnifiedDiff(w io.Writer, filename string, changes []lineChange, color bool) {
+	fmt.Fprintf(w, "--- %s\n", filename)
+	fmt.Fprintf(w, "+++ %s\n", filename)
+	// Multiline values expand one line into several, shifting later lines
+	offset := 0
+	for _, change := range changes {
+		newLines := strings.Split(change.newText, "\n")
+		fmt.Fprintf(w, "@@ -%d,1 +%d,%d @@\n", change.line, change.line+offset, len(newLines))
+		fmt.Fprintln(w, paint("-"+change.oldText, colorRemoved, color))
+		for _, line := range newLines {
+			fmt.Fprintln(w, paint("+"+line, colorAdded, color))
+		}
+		offset += len(newLines) - 1
+	}
+}
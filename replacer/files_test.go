@@ -0,0 +1,446 @@
+package replacer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMarkUnresolved(t *testing.T) {
+	src := `package api
+
+const A = 1
+
+// {{A}} {{Missing}} {{Missing}} ${Other}
+var s = "keep // TODO: unresolved this"
+
+func f() {} // TODO: unresolved race in the scheduler
+`
+	want := `package api
+
+const A = 1
+
+// 1 {{Missing}} {{Missing}} ${Other} // TODO: unresolved {{Missing}}, ${Other}
+var s = "keep // TODO: unresolved this"
+
+func f() {} // TODO: unresolved race in the scheduler
+`
+	path := writeTestFile(t, t.TempDir(), "a.go", src)
+	for run := 1; run <= 2; run++ {
+		r := NewSwaggerVariableReplacer()
+		r.SetMarkUnresolved(true)
+		if _, err := r.ProcessFile(path); err != nil {
+			t.Fatal(err)
+		}
+		if got := readTestFile(t, path); got != want {
+			t.Fatalf("run %d:\ngot:\n%s\nwant:\n%s", run, got, want)
+		}
+	}
+}
+
+func TestMarkUnresolvedDroppedOnceResolved(t *testing.T) {
+	r := NewSwaggerVariableReplacer()
+	r.SetMarkUnresolved(true)
+	src := "package api\n\nconst Missing = 2\n\n// {{Missing}} // TODO: unresolved {{Missing}}\n"
+	want := "package api\n\nconst Missing = 2\n\n// 2\n"
+	if got := process(t, r, src); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMarkUnresolvedAfterCode(t *testing.T) {
+	r := NewSwaggerVariableReplacer()
+	r.SetMarkUnresolved(true)
+	src := "package api\n\n/* {{Gone}} */ var x = 1\n"
+	want := "package api\n\n/* {{Gone}} */ var x = 1 // TODO: unresolved {{Gone}}\n"
+	got := process(t, r, src)
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if again := process(t, NewSwaggerVariableReplacer(), got); again != got {
+		t.Errorf("changed again without SetMarkUnresolved: %q", again)
+	}
+}
+
+func TestTrailingNewlinePreserved(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"with newline", "package api\n\nconst A = 1\n\n// {{A}}\n", "package api\n\nconst A = 1\n\n// 1\n"},
+		{"without newline", "package api\n\nconst A = 1\n\n// {{A}}", "package api\n\nconst A = 1\n\n// 1"},
+		{"several newlines", "package api\n\nconst A = 1\n\n// {{A}}\n\n\n", "package api\n\nconst A = 1\n\n// 1\n\n\n"},
+		{"unchanged without newline", "package api\n\nconst A = 1", "package api\n\nconst A = 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestFile(t, t.TempDir(), "a.go", tt.src)
+			if _, err := NewSwaggerVariableReplacer().ProcessFile(path); err != nil {
+				t.Fatal(err)
+			}
+			if got := readTestFile(t, path); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDryRunColor(t *testing.T) {
+	for _, color := range []bool{false, true} {
+		path := writeTestFile(t, t.TempDir(), "a.go", "package api\n\nconst A = 1\n\n// {{A}}\n")
+		r := NewSwaggerVariableReplacer()
+		r.SetDryRun(true)
+		r.SetColor(color)
+		out := capture(t, &os.Stdout, func() {
+			if _, err := r.ProcessFile(path); err != nil {
+				t.Fatal(err)
+			}
+		})
+		colored := strings.Contains(out, "\x1b[")
+		if colored != color {
+			t.Errorf("color %v: got output %q", color, out)
+		}
+		if color && (!strings.Contains(out, colorRemoved+"-// {{A}}"+colorReset) || !strings.Contains(out, colorAdded+"+// 1"+colorReset)) {
+			t.Errorf("lines not colored: %q", out)
+		}
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	src := "package api\n\nconst A = 1\n\n// keep\n// {{A}}\n"
+	path := writeTestFile(t, t.TempDir(), "a.go", src)
+	var lines int
+	out := capture(t, &os.Stdout, func() {
+		var err error
+		if lines, err = NewSwaggerVariableReplacer().DryRun(path); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if lines != 1 {
+		t.Errorf("DryRun = %d, want 1", lines)
+	}
+	if !strings.Contains(out, "@@ -6,1 +6,1 @@\n-// {{A}}\n+// 1\n") {
+		t.Errorf("unexpected diff:\n%s", out)
+	}
+	if got := readTestFile(t, path); got != src {
+		t.Errorf("file written in dry run: %q", got)
+	}
+}
+
+func TestDryRunDirectory(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n\n// {{A}}\n")
+	b := writeTestFile(t, dir, "b.go", "package api\n\n// {{A}} {{A}}\n// ${A}\n")
+	var lines int
+	capture(t, &os.Stdout, func() {
+		var err error
+		if lines, err = NewSwaggerVariableReplacer().DryRunDirectory(dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if lines != 3 {
+		t.Errorf("DryRunDirectory = %d, want 3", lines)
+	}
+	if got := readTestFile(t, a); strings.Contains(got, "// 1") {
+		t.Errorf("a.go written in dry run")
+	}
+	if got := readTestFile(t, b); strings.Contains(got, "// 1") {
+		t.Errorf("b.go written in dry run")
+	}
+}
+
+func TestDryRunDirectoryReportsEachFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n\n// {{A}}\n")
+	b := writeTestFile(t, dir, "sub/b.go", "package sub\n\nconst B = 2\n\n// {{B}}\n")
+	c := writeTestFile(t, dir, "sub/c.go", "package sub\n\n// {{B}} {{B}}\n")
+
+	r := NewSwaggerVariableReplacer()
+	var lines int
+	out := capture(t, &os.Stdout, func() {
+		var err error
+		if lines, err = r.DryRunDirectory(dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if lines != 3 {
+		t.Errorf("DryRunDirectory = %d, want 3", lines)
+	}
+	for _, path := range []string{b, c} {
+		if !strings.Contains(out, "+++ "+path+"\n") {
+			t.Errorf("no diff for %s in:\n%s", path, out)
+		}
+	}
+	if got := readTestFile(t, c); got != "package sub\n\n// {{B}} {{B}}\n" {
+		t.Errorf("c.go written in dry run: %q", got)
+	}
+	if r.dryRun {
+		t.Error("dry run mode left on")
+	}
+}
+
+func TestFileModePreserved(t *testing.T) {
+	path := writeTestFile(t, t.TempDir(), "a.go", "package api\n\nconst A = 1\n\n// {{A}}\n")
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSwaggerVariableReplacer().ProcessFile(path); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("mode = %o, want 600", mode)
+	}
+	if got := readTestFile(t, path); !strings.Contains(got, "// 1\n") {
+		t.Errorf("not substituted: %q", got)
+	}
+}
+
+func TestFailedWriteKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	src := "package api\n\nconst A = 1\n\n// {{A}}\n"
+	path := writeTestFile(t, dir, "a.go", src)
+
+	rename = func(string, string) error { return errors.New("disk full") }
+	defer func() { rename = os.Rename }()
+
+	if _, err := NewSwaggerVariableReplacer().ProcessFile(path); err == nil {
+		t.Fatal("ProcessFile succeeded despite the failed write")
+	}
+	if got := readTestFile(t, path); got != src {
+		t.Errorf("original modified: %q", got)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+}
+
+func TestBackupOnlyOnChange(t *testing.T) {
+	dir := t.TempDir()
+	src := "package api\n\nconst A = 1\n\n// {{A}}\n"
+	changed := writeTestFile(t, dir, "a.go", src)
+	unchanged := writeTestFile(t, dir, "b.go", "package api\n\n// nothing\n")
+	stale := writeTestFile(t, dir, "b.go.backup", "stale")
+
+	for _, path := range []string{changed, unchanged} {
+		r := NewSwaggerVariableReplacer()
+		r.SetBackup(true)
+		if _, err := r.ProcessFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := readTestFile(t, changed+".backup"); got != src {
+		t.Errorf("backup = %q, want the original %q", got, src)
+	}
+	if got := readTestFile(t, stale); got != "stale" {
+		t.Errorf("backup of an unchanged file overwritten: %q", got)
+	}
+
+	// A dry run writes no backup either
+	other := writeTestFile(t, dir, "c.go", src)
+	r := NewSwaggerVariableReplacer()
+	r.SetBackup(true)
+	r.SetDryRun(true)
+	capture(t, &os.Stdout, func() {
+		if _, err := r.ProcessFile(other); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if _, err := os.Stat(other + ".backup"); !os.IsNotExist(err) {
+		t.Errorf("backup written in dry run: %v", err)
+	}
+}
+
+func TestCRLFPreserved(t *testing.T) {
+	src := "package api\r\n\r\nconst A = 1\r\n\r\n// {{A}}\r\n/* multi\r\n   {{A}} */\r\nvar s = \"x\" // {{A}}\r\n"
+	want := strings.ReplaceAll(src, "{{A}}", "1")
+	path := writeTestFile(t, t.TempDir(), "crlf.go", src)
+	if _, err := NewSwaggerVariableReplacer().ProcessFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, path); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n\n// unchanged\n\t// first {{A}}\n// second ${A}\n")
+	writeTestFile(t, dir, "b.go", "package api\n\n// nothing\n")
+	for verbose, want := range map[bool]string{
+		false: "a.go\n  // first {{A}} -> // first 1\n  // second ${A} -> // second 1\n",
+		true:  "a.go\n  6: // first {{A}} -> // first 1\n  7: // second ${A} -> // second 1\n",
+	} {
+		r := NewSwaggerVariableReplacer()
+		r.SetDryRun(true)
+		r.SetSummary(true)
+		r.SetVerbose(verbose)
+		r.SetRoot(dir)
+		var out string
+		capture(t, &os.Stderr, func() {
+			out = capture(t, &os.Stdout, func() {
+				if err := r.ProcessDirectory(dir); err != nil {
+					t.Fatal(err)
+				}
+			})
+		})
+		if out != want {
+			t.Errorf("verbose %v: got %q, want %q", verbose, out, want)
+		}
+	}
+}
+
+func TestGofmt(t *testing.T) {
+	src := "package api\n\nconst A = 1\n\n// {{A}}\nfunc  f( )  {  }\n"
+	want := "package api\n\nconst A = 1\n\n// 1\nfunc f() {}\n"
+	path := writeTestFile(t, t.TempDir(), "a.go", src)
+	r := NewSwaggerVariableReplacer()
+	r.SetGofmt(true)
+	if _, err := r.ProcessFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, path); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// A value that breaks the syntax is an error, and nothing is written
+	src = "package api\n\nconst Close = \"*/ func {\"\n\n/* {{Close}} */\n"
+	path = writeTestFile(t, t.TempDir(), "b.go", src)
+	if _, err := r.ProcessFile(path); err == nil {
+		t.Error("unparseable result written")
+	}
+	if got := readTestFile(t, path); got != src {
+		t.Errorf("file modified: %q", got)
+	}
+}
+
+func TestGofmtKeepsCRLF(t *testing.T) {
+	src := "\ufeffpackage api\r\n\r\nconst A = 1\r\n\r\n// {{A}}\r\n/* multi\r\n   {{A}} */\r\nfunc  f( )  {  }\r\n"
+	want := "\ufeffpackage api\r\n\r\nconst A = 1\r\n\r\n// 1\r\n/* multi\r\n   1 */\r\nfunc f() {}\r\n"
+	path := writeTestFile(t, t.TempDir(), "crlf.go", src)
+	r := NewSwaggerVariableReplacer()
+	r.SetGofmt(true)
+	if _, err := r.ProcessFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, path); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBackupMode(t *testing.T) {
+	path := writeTestFile(t, t.TempDir(), "a.go", "package api\n\nconst A = 1\n\n// {{A}}\n")
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	// An older backup doesn't keep its wider permissions
+	writeTestFile(t, filepath.Dir(path), "a.go.backup", "stale")
+	r := NewSwaggerVariableReplacer()
+	r.SetBackup(true)
+	if _, err := r.ProcessFile(path); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path + ".backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("backup mode = %o, want 600", mode)
+	}
+}
+
+func TestBackupLocation(t *testing.T) {
+	dir := t.TempDir()
+	src := "package api\n\nconst A = 1\n\n// {{A}}\n"
+	path := writeTestFile(t, dir, "api/v1/a.go", src)
+
+	r := NewSwaggerVariableReplacer()
+	r.SetBackup(true)
+	r.SetBackupSuffix(".bak")
+	if _, err := r.ProcessFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, path+".bak"); got != src {
+		t.Errorf("backup with suffix = %q", got)
+	}
+
+	writeTestFile(t, dir, "api/v1/a.go", src)
+	backups := t.TempDir()
+	r = NewSwaggerVariableReplacer()
+	r.SetBackup(true)
+	r.SetBackupDir(backups)
+	r.SetRoot(dir)
+	if _, err := r.ProcessFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, backups+"/api/v1/a.go.backup"); got != src {
+		t.Errorf("mirrored backup = %q", got)
+	}
+	if _, err := os.Stat(path + ".backup"); !os.IsNotExist(err) {
+		t.Errorf("backup written next to the file: %v", err)
+	}
+}
+
+func TestByteOrderMark(t *testing.T) {
+	src := "\ufeff// Package api is {{Name}}\npackage api\n\nconst Name = \"svc\"\n"
+	want := "\ufeff// Package api is svc\npackage api\n\nconst Name = \"svc\"\n"
+	for _, ast := range []bool{false, true} {
+		path := writeTestFile(t, t.TempDir(), "a.go", src)
+		r := NewSwaggerVariableReplacer()
+		r.SetASTComments(ast)
+		if _, err := r.ProcessFile(path); err != nil {
+			t.Fatal(err)
+		}
+		if got := readTestFile(t, path); got != want {
+			t.Errorf("ast %v: got %q, want %q", ast, got, want)
+		}
+	}
+}
+
+func TestReadOnlyFiles(t *testing.T) {
+	src := "package api\n\nconst A = 1\n\n// {{A}}\n"
+	for _, write := range []bool{false, true} {
+		dir := t.TempDir()
+		ro := writeTestFile(t, dir, "a.go", src)
+		b := writeTestFile(t, dir, "b.go", "package api\n\n// {{A}}\n")
+		if err := os.Chmod(ro, 0444); err != nil {
+			t.Fatal(err)
+		}
+
+		r := NewSwaggerVariableReplacer()
+		r.SetWriteReadOnly(write)
+		var err error
+		stderr := capture(t, &os.Stderr, func() { err = r.ProcessDirectory(dir) })
+		if got := readTestFile(t, b); !strings.HasSuffix(got, "// 1\n") {
+			t.Errorf("write %v: other file not processed: %q", write, got)
+		}
+		info, statErr := os.Stat(ro)
+		if statErr != nil {
+			t.Fatal(statErr)
+		}
+		if mode := info.Mode().Perm(); mode != 0444 {
+			t.Errorf("write %v: mode = %o, want 444", write, mode)
+		}
+		got := readTestFile(t, ro)
+		if write {
+			if err != nil || got == src {
+				t.Errorf("read-only file not written: %v, %q", err, got)
+			}
+			continue
+		}
+		if err == nil || got != src {
+			t.Errorf("read-only file: err = %v, content %q", err, got)
+		}
+		if !strings.Contains(stderr, "Skipped 1 read-only file(s): "+ro) {
+			t.Errorf("no summary in %q", stderr)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package replacer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// process substitutes src in memory with r, failing the test on error
+func process(t *testing.T, r *SwaggerVariableReplacer, src string) string {
+	t.Helper()
+	out, err := r.ProcessSource(nil, []byte(src))
+	if err != nil {
+		t.Fatalf("ProcessSource: %v", err)
+	}
+	return string(out)
+}
+
+// writeTestFile writes content to name under dir, creating directories as
+// needed, and returns its path
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readTestFile returns the content of path, failing the test on error
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+// capture returns what fn writes to *f, which is os.Stdout or os.Stderr
+func capture(t *testing.T, f **os.File, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := *f
+	*f = w
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+	defer func() { *f = saved }()
+	fn()
+	w.Close()
+	return <-done
+}
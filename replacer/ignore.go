@@ -0,0 +1,72 @@
+package replacer
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFileName is the file listing paths to skip, with .gitignore syntax,
+// read from the root of each directory walk
+const ignoreFileName = ".gofmtcommentignore"
+
+// ignoreRule is a line of an ignore file
+type ignoreRule struct {
+	pattern string // relative to the ignore file's directory
+	negate  bool   // a !pattern re-including what earlier rules excluded
+	dirOnly bool   // a pattern/ matching directories only
+	// anchored rules only match paths relative to the ignore file's
+	// directory, not base names at any depth
+	anchored bool
+}
+
+// matches reports whether rel, relative to the ignore file's directory,
+// matches the rule's pattern
+func (rule ignoreRule) matches(rel string) bool {
+	if rule.anchored {
+		return matchGlob(rule.pattern, rel)
+	}
+	return matchExclude(rule.pattern, rel)
+}
+
+// readIgnoreFile reads the rules of the ignore file in dir, returning none
+// if there is no such file. Blank lines and # comments are skipped, and a
+// leading slash anchors a pattern to dir as in .gitignore.
+func readIgnoreFile(dir string) ([]ignoreRule, error) {
+	f, err := os.Open(filepath.Join(dir, ignoreFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate, line = true, line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:] // \! and \# escape a literal first character
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly, line = true, strings.TrimSuffix(line, "/")
+		}
+		// A pattern with a slash is relative to dir; a leading one only
+		// marks it as such
+		rule.anchored = strings.Contains(line, "/")
+		rule.pattern = strings.TrimPrefix(line, "/")
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
@@ -0,0 +1,63 @@
+package replacer
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+	src := "package api\n\nconst A = 1\n\n// {{A}}\n"
+	writeTestFile(t, dir, ignoreFileName, "# generated clients\nclients/\n*_mock.go\n!keep_mock.go\n")
+	for _, name := range []string{"a.go", "clients/c.go", "clients/deep/d.go", "x_mock.go", "keep_mock.go", "sub/y_mock.go"} {
+		writeTestFile(t, dir, name, src)
+	}
+
+	r := NewSwaggerVariableReplacer()
+	capture(t, &os.Stderr, func() {
+		if err := r.ProcessDirectory(dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+	for name, ignored := range map[string]bool{
+		"a.go":              false,
+		"clients/c.go":      true,
+		"clients/deep/d.go": true,
+		"x_mock.go":         true,
+		"sub/y_mock.go":     true,
+		"keep_mock.go":      false,
+	} {
+		got := readTestFile(t, dir+"/"+name)
+		if (got == src) != ignored {
+			t.Errorf("%s: ignored %v, got %q", name, ignored, got)
+		}
+	}
+}
+
+func TestIgnoreFileMergedWithExcludes(t *testing.T) {
+	dir := t.TempDir()
+	src := "package api\n\nconst A = 1\n\n// {{A}}\n"
+	writeTestFile(t, dir, ignoreFileName, "a.go\n")
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		writeTestFile(t, dir, name, src)
+	}
+	r := NewSwaggerVariableReplacer()
+	if err := r.ApplyConfig(&Config{ExcludeFiles: []string{"b.go"}}); err != nil {
+		t.Fatal(err)
+	}
+	capture(t, &os.Stderr, func() {
+		if err := r.ProcessDirectory(dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+	var changed []string
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		if readTestFile(t, dir+"/"+name) != src {
+			changed = append(changed, name)
+		}
+	}
+	if strings.Join(changed, " ") != "c.go" {
+		t.Errorf("changed %v, want only c.go", changed)
+	}
+}
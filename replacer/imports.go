@@ -0,0 +1,177 @@
+package replacer
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PackageResolver loads the package with the given import path, returning
+// nil if it can't be loaded. The package's declared name resolves imports
+// without an explicit name, and its exported constants resolve placeholders
+// qualified by the name a file imports it under, as in {{h.StatusOK}} after
+// import h "net/http", or unqualified after a dot import.
+type PackageResolver func(importPath string) *types.Package
+
+// SetPackageResolver sets the resolver for constants of imported packages
+// that weren't added with AddPackage; nil, the default, leaves them
+// unresolved
+func (r *SwaggerVariableReplacer) SetPackageResolver(resolver PackageResolver) {
+	r.resolver = resolver
+}
+
+// ImporterResolver returns a PackageResolver that type-checks imported
+// packages from source with go/importer, which finds the standard library
+// and packages in GOPATH. Each package is loaded at most once.
+func ImporterResolver() PackageResolver {
+	imp := importer.ForCompiler(token.NewFileSet(), "source", nil)
+	var mu sync.Mutex
+	packages := make(map[string]*types.Package)
+	return func(importPath string) *types.Package {
+		mu.Lock()
+		defer mu.Unlock()
+		pkg, loaded := packages[importPath]
+		if !loaded {
+			pkg, _ = imp.Import(importPath) // nil if it can't be loaded
+			packages[importPath] = pkg
+		}
+		return pkg
+	}
+}
+
+// constantValue converts a go/constant value to the types of values
+// extracted from source
+func constantValue(v constant.Value) (interface{}, bool) {
+	switch v.Kind() {
+	case constant.Bool:
+		return constant.BoolVal(v), true
+	case constant.String:
+		return constant.StringVal(v), true
+	case constant.Int:
+		if i, exact := constant.Int64Val(v); exact && int64(int(i)) == i {
+			return int(i), true
+		}
+	case constant.Float:
+		f, _ := constant.Float64Val(v)
+		return f, true
+	}
+	return nil, false
+}
+
+// fileImports are the imports of a file
+type fileImports struct {
+	// named maps the explicit names of imports to their paths
+	named map[string]string
+	// unnamed holds the paths imported under their package's own name
+	unnamed []string
+	// dot holds the paths imported with a dot, whose names need no qualifier
+	dot []string
+}
+
+// recordImports remembers how filename imports each package
+func (r *SwaggerVariableReplacer) recordImports(filename string, file *ast.File) {
+	imports := fileImports{named: make(map[string]string)}
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		switch {
+		case spec.Name == nil:
+			imports.unnamed = append(imports.unnamed, importPath)
+		case spec.Name.Name == ".":
+			imports.dot = append(imports.dot, importPath)
+		case spec.Name.Name != "_":
+			imports.named[spec.Name.Name] = importPath
+		}
+	}
+	r.imports[filename] = imports
+}
+
+// lookupImport resolves a name qualified by the name file imports a
+// package under, or a bare name from the packages it dot imports. Imports
+// without an explicit name are matched by their package name, tried first
+// for those whose path suggests it, as loading packages can be slow.
+func (r *SwaggerVariableReplacer) lookupImport(file, name string) (ConstantInfo, bool) {
+	imports := r.imports[file]
+	if pkg, rest, qualified := strings.Cut(name, "."); qualified {
+		if importPath, named := imports.named[pkg]; named {
+			return r.importedConstant(importPath, rest)
+		}
+		for _, guessed := range []bool{true, false} {
+			for _, importPath := range imports.unnamed {
+				if (guessPackageName(importPath) == pkg) == guessed && r.packageName(importPath) == pkg {
+					return r.importedConstant(importPath, rest)
+				}
+			}
+		}
+	}
+	for _, importPath := range imports.dot {
+		if info, exists := r.importedConstant(importPath, name); exists {
+			return info, true
+		}
+	}
+	return ConstantInfo{}, false
+}
+
+// importedConstant resolves the constant name of the package with
+// importPath, from the package added with AddPackage under its name, or
+// else with the package resolver
+func (r *SwaggerVariableReplacer) importedConstant(importPath, name string) (ConstantInfo, bool) {
+	if info, exists := r.constants[r.packageName(importPath)+"."+name]; exists {
+		return info, true
+	}
+	if r.resolver == nil || !token.IsExported(name) {
+		return ConstantInfo{}, false
+	}
+	pkg := r.resolver(importPath)
+	if pkg == nil {
+		return ConstantInfo{}, false
+	}
+	c, isConst := pkg.Scope().Lookup(name).(*types.Const)
+	if !isConst {
+		return ConstantInfo{}, false
+	}
+	value, ok := constantValue(c.Val())
+	return ConstantInfo{Value: value}, ok
+}
+
+// packageName returns the name declared by the package with importPath, as
+// loaded by the package resolver, or else as guessed from the path
+func (r *SwaggerVariableReplacer) packageName(importPath string) string {
+	if r.resolver != nil {
+		if pkg := r.resolver(importPath); pkg != nil {
+			return pkg.Name()
+		}
+	}
+	return guessPackageName(importPath)
+}
+
+// guessPackageName guesses the name of a package from its import path, as
+// its last element without a major version, so that both example.com/mod/v2
+// and gopkg.in/yaml.v3 give their previous element
+func guessPackageName(importPath string) string {
+	name := path.Base(importPath)
+	if isMajorVersion(name) && path.Dir(importPath) != "." {
+		name = path.Base(path.Dir(importPath))
+	}
+	if base, version, found := strings.Cut(name, "."); found && strings.HasPrefix(importPath, "gopkg.in/") && isMajorVersion(version) {
+		name = base
+	}
+	return name
+}
+
+// isMajorVersion reports whether s is a major version such as v2
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(s[1:])
+	return err == nil
+}
@@ -0,0 +1,87 @@
+package replacer
+
+import (
+	"go/constant"
+	"go/token"
+	"go/types"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAliasedStdlibImport(t *testing.T) {
+	src := `package api
+
+import (
+	u "unicode/utf8"
+	"math"
+)
+
+var _ = u.RuneSelf
+var _ = math.Pi
+
+// {{u.RuneSelf}} {{math.MaxInt8}} {{utf8.RuneSelf}} {{u.NoSuchConstant}}
+`
+	r := NewSwaggerVariableReplacer()
+	r.SetPackageResolver(ImporterResolver())
+	var got string
+	stderr := capture(t, &os.Stderr, func() { got = process(t, r, src) })
+	if want := "// 128 127 {{utf8.RuneSelf}} {{u.NoSuchConstant}}\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("got %q, want it to end with %q", got, want)
+	}
+	if !strings.Contains(stderr, "'u.NoSuchConstant' not found") {
+		t.Errorf("no warning in %q", stderr)
+	}
+
+	if got := process(t, NewSwaggerVariableReplacer(), src); !strings.Contains(got, "{{u.RuneSelf}}") {
+		t.Errorf("resolved without a resolver: %q", got)
+	}
+}
+
+func TestDotImport(t *testing.T) {
+	src := `package api
+
+import . "unicode/utf8"
+
+var _ = RuneSelf
+
+// {{RuneSelf}} {{UTFMax}} {{NoSuchConstant}}
+`
+	r := NewSwaggerVariableReplacer()
+	r.SetPackageResolver(ImporterResolver())
+	var got string
+	capture(t, &os.Stderr, func() { got = process(t, r, src) })
+	if want := "// 128 4 {{NoSuchConstant}}\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("got %q, want it to end with %q", got, want)
+	}
+}
+
+func TestImportedPackageNames(t *testing.T) {
+	src := `package api
+
+import (
+	"example.com/go-thing"
+	"example.com/mod/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// {{thing.Max}} {{mod.Name}} {{yaml.Tab}} {{v2.Name}}
+`
+	thing := types.NewPackage("example.com/go-thing", "thing")
+	thing.Scope().Insert(types.NewConst(token.NoPos, thing, "Max", types.Typ[types.UntypedInt], constant.MakeInt64(9)))
+
+	r := NewSwaggerVariableReplacer()
+	r.AddPackageConstants("mod", map[string]interface{}{"Name": "m"})
+	r.AddPackageConstants("yaml", map[string]interface{}{"Tab": 2})
+	r.SetPackageResolver(func(importPath string) *types.Package {
+		if importPath == thing.Path() {
+			return thing
+		}
+		return nil
+	})
+	var got string
+	capture(t, &os.Stderr, func() { got = process(t, r, src) })
+	if want := "// 9 m 2 {{v2.Name}}\n"; !strings.HasSuffix(got, want) {
+		t.Errorf("got %q, want it to end with %q", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+package replacer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Index is the constant table of a whole module, built once with BuildIndex
+// and saved to disk so that later runs can load it with LoadIndex instead of
+// extracting every file again. An index isn't updated when files change;
+// build it again instead.
+type Index struct {
+	Constants []IndexEntry `json:"constants"`
+}
+
+// IndexEntry is a constant of an Index along with where it was defined
+type IndexEntry struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+	Type  string      `json:"type"` // int, float64, rune, string or bool
+	File  string      `json:"file,omitempty"`
+	Line  int         `json:"line,omitempty"`
+	Raw   string      `json:"raw,omitempty"` // the numeric literal as written in source
+}
+
+// BuildIndex extracts the constants of every Go file under root, like
+// ExtractFromDir, and returns them as an Index sorted by name
+func (r *SwaggerVariableReplacer) BuildIndex(root string) (*Index, error) {
+	if err := r.ExtractFromDir(root); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(r.constants))
+	for name := range r.constants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	index := &Index{Constants: make([]IndexEntry, 0, len(names))}
+	for _, name := range names {
+		info := r.constants[name]
+		index.Constants = append(index.Constants, IndexEntry{
+			Name:  name,
+			Value: info.Value,
+			Type:  typeName(info.Value),
+			File:  info.File,
+			Line:  info.Line,
+			Raw:   info.Raw,
+		})
+	}
+	return index, nil
+}
+
+// Save writes the index to path as JSON
+func (ix *Index) Save(path string) error {
+	content, err := json.MarshalIndent(ix, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// LoadIndex reads an index saved with Save, restoring the Go type of each
+// value from its recorded type
+func LoadIndex(path string) (*Index, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %v", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var index Index
+	if err := decoder.Decode(&index); err != nil {
+		return nil, fmt.Errorf("failed to parse index %s: %v", path, err)
+	}
+
+	for i, entry := range index.Constants {
+		value, ok := indexValue(entry.Type, entry.Value)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s value %v for %s in index %s", entry.Type, entry.Value, entry.Name, path)
+		}
+		index.Constants[i].Value = value
+	}
+	return &index, nil
+}
+
+// indexValue converts a value decoded from JSON back to the Go type named
+// by typ
+func indexValue(typ string, value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		switch typ {
+		case "int", "rune":
+			i, err := v.Int64()
+			if err != nil {
+				return nil, false
+			}
+			if typ == "rune" {
+				return rune(i), true
+			}
+			return int(i), true
+		case "float64":
+			f, err := v.Float64()
+			return f, err == nil
+		}
+	case string:
+		return v, typ == "string"
+	case bool:
+		return v, typ == "bool"
+	}
+	return nil, false
+}
+
+// UseIndex adds the constants of index to the table, keeping where each was
+// defined. Like constants of data files, they can be referenced from every
+// file, and constants extracted from processed files take precedence.
+func (r *SwaggerVariableReplacer) UseIndex(index *Index) {
+	for _, entry := range index.Constants {
+		r.constants[entry.Name] = ConstantInfo{Value: entry.Value, File: entry.File, Line: entry.Line, Raw: entry.Raw}
+	}
+}
@@ -0,0 +1,74 @@
+package replacer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIndexRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, root, "consts/consts.go", `package consts
+
+const (
+	Port    = 0x1F90
+	Ratio   = 0.5
+	Sep     = ','
+	Name    = "svc"
+	Enabled = true
+)
+`)
+	index, err := NewSwaggerVariableReplacer().BuildIndex(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range index.Constants {
+		names = append(names, entry.Name)
+	}
+	if got := strings.Join(names, " "); got != "Enabled Name Port Ratio Sep" {
+		t.Errorf("indexed %s", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := index.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, entry := range loaded.Constants {
+		if want := index.Constants[i]; entry != want {
+			t.Errorf("loaded %#v, want %#v", entry, want)
+		}
+	}
+
+	file := writeTestFile(t, t.TempDir(), "api/a.go", "package api\n\n// {{Port}} {{Ratio}} {{Sep}} {{Name}} {{Enabled}}\n")
+	r := NewSwaggerVariableReplacer()
+	r.UseIndex(loaded)
+	capture(t, &os.Stderr, func() {
+		if _, err := r.ProcessFile(file); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if got := readTestFile(t, file); !strings.HasSuffix(got, "// 0x1F90 0.5 , svc true\n") {
+		t.Errorf("substituted from index: %q", got)
+	}
+}
+
+func TestLoadIndexErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"syntax.json": "{",
+		"type.json":   `{"constants": [{"name": "A", "value": "x", "type": "int"}]}`,
+	} {
+		if _, err := LoadIndex(writeTestFile(t, dir, name, content)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+	if _, err := LoadIndex(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("missing index: no error")
+	}
+}
@@ -0,0 +1,72 @@
+package replacer
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// nearMissPattern matches text shaped like a built-in placeholder whatever
+// its delimiters, such as {Name}, {{Name} or @VAR(Name, to find typos among
+// what isn't a real placeholder
+var nearMissPattern = regexp.MustCompile(`\$?\{\{?\s*(` + namePattern + `)\s*\}?\}?|@VAR\(\s*(` + namePattern + `)\s*\)?`)
+
+// SetLintPlaceholders makes substitution warn about text in comments that
+// looks like a placeholder with mistyped delimiters, such as {Name} or
+// {{Name}, which would otherwise be left in place silently. In strict mode,
+// such text fails the run with an error matching ErrMalformed.
+func (r *SwaggerVariableReplacer) SetLintPlaceholders(enabled bool) {
+	r.lintPlaceholders = enabled
+}
+
+// lintComment warns about the near-miss placeholders of a comment line,
+// found at offset in its source line, ignoring those overlapping the real
+// placeholders found in it. A name in single braces, as in the {id} of a
+// URL path, is only suspect when it is a known variable.
+func (r *SwaggerVariableReplacer) lintComment(line string, placeholders []placeholder, lineNo, offset int, res *Result) {
+	for _, m := range nearMissPattern.FindAllStringSubmatchIndex(line, -1) {
+		overlaps := false
+		for _, p := range placeholders {
+			overlaps = overlaps || (m[0] < p.end && p.start < m[1])
+		}
+		if overlaps {
+			continue
+		}
+
+		start := m[0] + len(line[m[0]:m[1]]) - len(strings.TrimLeft(line[m[0]:m[1]], " \t"))
+		suspect := strings.TrimSpace(line[m[0]:m[1]])
+		if m[2] >= 0 && !strings.HasPrefix(suspect, "$") && !strings.HasPrefix(suspect, "{{") && !strings.HasSuffix(suspect, "}}") {
+			if _, known := r.lookup(res.File, line[m[2]:m[3]]); !known {
+				continue
+			}
+		}
+		if !r.gccWarning(res.File, lineNo, offset+start+1, "possibly malformed placeholder %q", suspect) {
+			fmt.Fprintf(os.Stderr, "Warning: %s:%d: possibly malformed placeholder %q\n", res.File, lineNo, suspect)
+		}
+		res.malformed = append(res.malformed, malformedPlaceholder{text: suspect, file: res.File, line: lineNo, col: offset + start + 1})
+	}
+}
+
+// malformedPlaceholder is text found by SetLintPlaceholders
+type malformedPlaceholder struct {
+	text      string
+	file      string
+	line, col int
+}
+
+// malformedError returns an error listing the possibly malformed
+// placeholders found since index from, or nil if there were none or strict
+// mode is off
+func (r *SwaggerVariableReplacer) malformedError(from int) error {
+	if !r.strict || len(r.malformed) <= from {
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d possibly malformed placeholder(s):", len(r.malformed)-from)
+	for _, m := range r.malformed[from:] {
+		fmt.Fprintf(&b, "\n  %q at %s:%d:%d", m.text, m.file, m.line, m.col)
+	}
+	return &checkError{ErrMalformed, b.String()}
+}
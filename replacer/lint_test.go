@@ -0,0 +1,68 @@
+package replacer
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLintPlaceholders(t *testing.T) {
+	tests := []struct {
+		comment, warning string
+	}{
+		{"{StatusOK}", `a.go:5: possibly malformed placeholder "{StatusOK}"`},
+		{"{{StatusOK}", `a.go:5: possibly malformed placeholder "{{StatusOK}"`},
+		{"{StatusOK}}", `a.go:5: possibly malformed placeholder "{StatusOK}}"`},
+		{"${StatusOK", `a.go:5: possibly malformed placeholder "${StatusOK"`},
+		{"@VAR(StatusOK", `a.go:5: possibly malformed placeholder "@VAR(StatusOK"`},
+		{"/users/{id}", ""},
+		{"{{StatusOK}}", ""},
+	}
+	for _, tt := range tests {
+		path := writeTestFile(t, t.TempDir(), "a.go", "package api\n\nconst StatusOK = 200\n\n// "+tt.comment+"\n")
+		r := NewSwaggerVariableReplacer()
+		r.SetLintPlaceholders(true)
+		stderr := capture(t, &os.Stderr, func() {
+			if _, err := r.ProcessFile(path); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if tt.warning == "" {
+			if stderr != "" {
+				t.Errorf("%s: unexpected warning %q", tt.comment, stderr)
+			}
+			continue
+		}
+		if !strings.Contains(stderr, "Warning: ") || !strings.Contains(stderr, tt.warning) {
+			t.Errorf("%s: got %q, want a warning containing %q", tt.comment, stderr, tt.warning)
+		}
+	}
+}
+
+func TestLintPlaceholdersStrict(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\nconst StatusOK = 200\n\n// {{StatusOK}} {StatusOK}\n")
+	writeTestFile(t, dir, "b.go", "package api\n\n// {{StatusOK}\n")
+	r := NewSwaggerVariableReplacer()
+	r.SetLintPlaceholders(true)
+	r.SetStrict(true)
+	var err error
+	capture(t, &os.Stderr, func() { err = r.ProcessDirectory(dir) })
+	if !errors.Is(err, ErrMalformed) || errors.Is(err, ErrUnresolved) {
+		t.Fatalf("err = %v, want ErrMalformed only", err)
+	}
+	for _, want := range []string{`"{StatusOK}" at ` + dir + "/a.go:5:17", `"{{StatusOK}" at ` + dir + "/b.go:3:4"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%q missing from %q", want, err)
+		}
+	}
+
+	// Outside strict mode, findings are only warnings
+	r = NewSwaggerVariableReplacer()
+	r.SetLintPlaceholders(true)
+	capture(t, &os.Stderr, func() { err = r.ProcessDirectory(dir) })
+	if err != nil {
+		t.Errorf("err = %v without strict mode", err)
+	}
+}
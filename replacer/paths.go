@@ -0,0 +1,164 @@
+package replacer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProcessPaths processes any mix of files, directories and glob patterns
+// such as api/*.go, sharing one constant table across all of them:
+// constants are extracted from every path before any is substituted. A
+// path that fails is reported and skipped, and an error is returned once
+// all the others are processed; in strict mode, the first failure stops
+// the run.
+func (r *SwaggerVariableReplacer) ProcessPaths(args []string) error {
+	return r.ProcessPathsContext(context.Background(), args)
+}
+
+// ProcessPathsContext is like ProcessPaths, but stops and returns ctx's
+// error as soon as ctx is done, leaving every file either fully
+// substituted or untouched
+func (r *SwaggerVariableReplacer) ProcessPathsContext(ctx context.Context, args []string) error {
+	r.failed = make(map[string]bool)
+	r.readOnly = nil
+	paths, err := r.expandPaths(args)
+	if err != nil {
+		return err
+	}
+
+	from, fromResults, fromConflicts := len(r.unresolved), len(r.results), len(r.conflicts)
+	fromMalformed := len(r.malformed)
+	dirs := make(map[string]bool)
+	var ok []string
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		info, err := os.Stat(path)
+		if err == nil {
+			dirs[path] = info.IsDir()
+			if info.IsDir() {
+				err = r.extractDir(ctx, path)
+			} else {
+				err = r.ExtractFromFile(path)
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			if err := r.pathFailed(path, err); err != nil {
+				return err
+			}
+			continue
+		}
+		ok = append(ok, path)
+	}
+	if err := r.conflictError(fromConflicts); err != nil {
+		return err
+	}
+
+	for _, path := range ok {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var err error
+		if dirs[path] {
+			err = r.replaceDir(ctx, path)
+		} else if _, err = r.ReplaceInFile(path); err != nil {
+			err = r.pathFailed(path, err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	r.warnUnresolved(from)
+	r.printSummary(fromResults, from)
+	if err := r.failedError(); err != nil {
+		return err
+	}
+	return errors.Join(r.unresolvedError(from), r.malformedError(fromMalformed))
+}
+
+// pathFailed reports an error processing one of several paths and marks
+// the path as failed, or returns the error in strict mode so that the run
+// stops
+func (r *SwaggerVariableReplacer) pathFailed(path string, err error) error {
+	if r.strict {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	r.failed[path] = true
+	return nil
+}
+
+// failedError returns an error counting the paths that failed in the
+// current run, or nil if none did
+func (r *SwaggerVariableReplacer) failedError() error {
+	if len(r.failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d path(s) could not be processed", len(r.failed))
+}
+
+// expandPaths expands the glob patterns among args and drops duplicate
+// paths, as well as files inside a directory that is also given
+func (r *SwaggerVariableReplacer) expandPaths(args []string) ([]string, error) {
+	var expanded []string
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			expanded = append(expanded, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", arg, err)
+		}
+		if len(matches) == 0 {
+			if err := r.pathFailed(arg, fmt.Errorf("no files match %s", arg)); err != nil {
+				return nil, err
+			}
+		}
+		expanded = append(expanded, matches...)
+	}
+
+	seen := make(map[string]bool)
+	var dirs, paths []string
+	for _, path := range expanded {
+		path = filepath.Clean(path)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			dirs = append(dirs, path)
+		}
+	}
+
+	unique := paths[:0]
+	for _, path := range paths {
+		if !insideAny(path, dirs) {
+			unique = append(unique, path)
+		}
+	}
+	return unique, nil
+}
+
+// insideAny reports whether path lies strictly inside one of dirs
+func insideAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package replacer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "api/consts.go", "package api\n\nconst StatusOK = 200\n")
+	a := writeTestFile(t, dir, "api/a.go", "package api\n\n// {{StatusOK}} {{Handler}}\n")
+	h := writeTestFile(t, dir, "handlers/h.go", "package handlers\n\nconst Handler = \"h\"\n\n// {{StatusOK}}\n")
+	skipped := writeTestFile(t, dir, "api/doc.txt", "// {{StatusOK}}\n")
+
+	r := NewSwaggerVariableReplacer()
+	// The file is matched both by the glob and by name
+	args := []string{filepath.Join(dir, "api", "*.go"), a, filepath.Join(dir, "handlers"), filepath.Join(dir, "missing.go")}
+	var err error
+	stderr := capture(t, &os.Stderr, func() { err = r.ProcessPaths(args) })
+	if err == nil || !strings.Contains(stderr, "missing.go") {
+		t.Errorf("err = %v, stderr %q, want the missing path reported", err, stderr)
+	}
+	if got := readTestFile(t, a); got != "package api\n\n// 200 h\n" {
+		t.Errorf("a.go: %q", got)
+	}
+	if got := readTestFile(t, h); !strings.HasSuffix(got, "// 200\n") {
+		t.Errorf("h.go: %q", got)
+	}
+	if got := readTestFile(t, skipped); got != "// {{StatusOK}}\n" {
+		t.Errorf("doc.txt modified: %q", got)
+	}
+	if n := strings.Count(stderr, "Processed 3 file(s)"); n != 1 {
+		t.Errorf("files processed more than once: %q", stderr)
+	}
+}
+
+func TestProcessPathsStrictStopsAtFailure(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n\n// {{A}}\n")
+	r := NewSwaggerVariableReplacer()
+	r.SetStrict(true)
+	capture(t, &os.Stderr, func() {
+		if err := r.ProcessPaths([]string{filepath.Join(dir, "missing.go"), a}); err == nil {
+			t.Error("missing path accepted")
+		}
+	})
+	if got := readTestFile(t, a); strings.Contains(got, "// 1") {
+		t.Errorf("processed after a failure in strict mode: %q", got)
+	}
+}
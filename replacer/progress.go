@@ -0,0 +1,51 @@
+package replacer
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// progress is a counter of the files a directory phase has processed,
+// rewritten in place on a single line
+type progress struct {
+	mu    sync.Mutex
+	w     io.Writer
+	phase string // Extracting or Replacing
+	done  int
+	total int
+}
+
+// newProgress returns a counter for a phase over total files, or nil if
+// progress isn't enabled
+func (r *SwaggerVariableReplacer) newProgress(phase string, total int) *progress {
+	if r.progress == nil {
+		return nil
+	}
+	p := &progress{w: r.progress, phase: phase, total: total}
+	p.print()
+	return p
+}
+
+// step counts one more processed file
+func (p *progress) step() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	p.print()
+}
+
+// finish ends the counter's line
+func (p *progress) finish() {
+	if p == nil {
+		return
+	}
+	fmt.Fprintln(p.w)
+}
+
+func (p *progress) print() {
+	fmt.Fprintf(p.w, "\r%s %d/%d", p.phase, p.done, p.total)
+}
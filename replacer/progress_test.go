@@ -0,0 +1,30 @@
+package replacer
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n")
+	writeTestFile(t, dir, "b.go", "package api\n\n// {{A}}\n")
+
+	var out bytes.Buffer
+	r := NewSwaggerVariableReplacer()
+	r.SetProgress(&out)
+	stderr := capture(t, &os.Stderr, func() {
+		if err := r.ProcessDirectory(dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+	want := "\rExtracting 0/2\rExtracting 1/2\rExtracting 2/2\n" +
+		"\rReplacing 0/2\rReplacing 1/2\rReplacing 2/2\n"
+	if got := out.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if stderr != "Processed 2 file(s), 1 substitution(s), 0 unresolved\n" {
+		t.Errorf("unexpected logging: %q", stderr)
+	}
+}
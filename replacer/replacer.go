@@ -0,0 +1,1130 @@
+// Package replacer substitutes constant values into variable placeholders
+// found in Go comments, such as Swagger annotations.
+package replacer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/build"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SwaggerVariableReplacer processes Go files and replaces variable references in comments
+type SwaggerVariableReplacer struct {
+	constants map[string]ConstantInfo
+	// fileConstants holds the constants extracted from each file
+	fileConstants map[string]map[string]ConstantInfo
+	// configConstants holds the constant map of the applied config
+	configConstants map[string]ConstantInfo
+	// imports holds how each file imports packages
+	imports map[string]fileImports
+	// resolver looks up constants of imported packages
+	resolver PackageResolver
+	// override makes configConstants take precedence over extracted constants
+	override bool
+	// env resolves variables found nowhere else from the environment
+	env bool
+	// reverse holds the values to turn back into placeholders, longest
+	// first; nil unless in reverse mode
+	reverse   []reversal
+	conflicts []Conflict
+	patterns  []pattern
+	excludes  []string // glob patterns of files to skip in directory walks
+	// ignoreRules are read from the ignore file of the current walk's root
+	ignoreRules []ignoreRule
+	dryRun      bool // report changes as a diff instead of writing
+	summary     bool // report changes as before -> after lines instead of a diff
+	color       bool // colorize removed and added lines of dry-run output
+	check       bool // compute changes without writing or reporting them
+	backup      bool // back up files before modifying them
+	// backupSuffix is appended to backup file names, and backupDir, if set,
+	// holds backups instead of the directories of the files
+	backupSuffix, backupDir string
+	// root is the directory reported paths are relative to, if set
+	root    string
+	strict  bool // fail when variables can't be resolved
+	verbose bool // log each processed file and replaced line
+	align   bool // re-align comment columns after substitution
+	jobs    int  // files processed concurrently in directory mode
+	// preferLocal resolves a file's own constants before the shared table
+	preferLocal bool
+	// fileScope resolves a file's placeholders only from its own constants
+	fileScope bool
+	// buildContext filters files by build constraints; nil processes all
+	buildContext *build.Context
+	// followSymlinks walks into symlinked directories in directory mode
+	followSymlinks bool
+	// quiet suppresses informational messages
+	quiet bool
+	// warningFormat is how located warnings print: "prose" or "gcc"
+	warningFormat string
+	// writeReadOnly rewrites read-only files instead of skipping them
+	writeReadOnly bool
+	// readOnly holds the files skipped for being read-only in the current run
+	readOnly []string
+	// includeTests extracts constants from _test.go files in directory mode
+	includeTests bool
+	// processTests substitutes in _test.go files too in directory mode
+	processTests bool
+	// includeGenerated processes files with a generated code header too
+	includeGenerated bool
+	// maxDepth is how many directory levels below the root walks descend;
+	// -1 means no limit
+	maxDepth int
+	// stamps is the watch cache of the file versions last processed
+	stamps map[string]fileStamp
+	// failed holds the paths skipped after an error in the current run
+	failed map[string]bool
+	// maxUnresolved is how many unresolved variables a run tolerates; -1
+	// means no limit
+	maxUnresolved int
+	// stringNames holds what String methods return for constants, as found
+	// by extractStringer
+	stringNames map[string]string
+	// stringer substitutes constants with their stringNames entry
+	stringer bool
+	// runeCodes substitutes rune constants as their numeric code point
+	runeCodes bool
+	// progress receives a running count of the files processed in
+	// directory mode; nil disables it
+	progress io.Writer
+	// floatFormat is the fmt verb for float values; empty keeps the source text
+	floatFormat string
+	// boolTrue and boolFalse are substituted for bool values
+	boolTrue, boolFalse string
+	// astComments locates comments with go/parser rather than by scanning
+	// lines
+	astComments bool
+	// gofmt formats substituted files with go/format before writing them
+	gofmt bool
+	// noSource skips extracting constants from processed files
+	noSource bool
+	// inStrings substitutes placeholders in string literals too
+	inStrings bool
+	// replaceOnce substitutes only the first occurrence of each placeholder
+	// per comment line
+	replaceOnce bool
+	// structFields substitutes only in the comments of struct fields
+	structFields bool
+	// markUnresolved appends a marker comment to lines with unresolved
+	// placeholders
+	markUnresolved bool
+	// recursiveDepth is how many levels of placeholders inside substituted
+	// values are resolved; 0 inserts values literally
+	recursiveDepth int
+	// lintPlaceholders warns about placeholders with mistyped delimiters
+	lintPlaceholders bool
+	// transforms are the formats added with AddTransform
+	transforms map[string]Transform
+	// validator checks every substitution, see SetValidator
+	validator func(sub Substitution) error
+	// formatter renders resolved values in place of formatValue; nil keeps
+	// the built-in formatting
+	formatter func(name string, value interface{}) string
+	pending   int // lines that would change in dry-run mode
+
+	// holdPreviews keeps dry-run output in results while files are
+	// processed concurrently
+	holdPreviews bool
+
+	// mu guards the fields below and constants while files are processed
+	// concurrently
+	mu         sync.Mutex
+	results    []*Result              // one per processed file, in order
+	unresolved []unresolvedVar        // placeholders left in place, in order
+	malformed  []malformedPlaceholder // found with lintPlaceholders, in order
+}
+
+// Result describes what processing a single file did
+type Result struct {
+	File          string         `json:"file"`
+	LinesChanged  int            `json:"lines_changed"`
+	Substitutions []Substitution `json:"substitutions,omitempty"`
+	// Unresolved lists variables that couldn't be found, once each
+	Unresolved []string `json:"unresolved,omitempty"`
+
+	changes   []lineChange
+	missing   []unresolvedVar
+	malformed []malformedPlaceholder
+	preview   string  // dry-run output held back to print in walk order
+	invalid   []error // substitutions rejected by the validator
+}
+
+// Conflict is a constant name extracted with different values from two files
+type Conflict struct {
+	Name          string
+	Value         interface{}
+	Location      string // file:line of the earlier definition
+	OtherValue    interface{}
+	OtherLocation string // file:line of the later definition
+}
+
+// ConstantInfo is the value of a constant along with where it was defined
+type ConstantInfo struct {
+	Value interface{}
+	File  string // empty if it wasn't extracted from a file
+	Line  int
+	Raw   string // the numeric literal as written in source, if declared as one
+}
+
+// Location returns the file:line the constant was defined at, only the
+// file for data files, or "" if it wasn't extracted from a file
+func (c ConstantInfo) Location() string {
+	if c.File == "" || c.Line == 0 {
+		return c.File
+	}
+	return fmt.Sprintf("%s:%d", c.File, c.Line)
+}
+
+// Constant is an entry of the extracted constant table
+type Constant struct {
+	Name     string
+	Value    interface{}
+	Type     string // Go type of the value: int, float64, rune, string or bool
+	Location string // file:line of the definition, empty if it wasn't extracted from a file
+}
+
+// Substitution is a single placeholder replaced in a comment
+type Substitution struct {
+	Pattern string      `json:"pattern"` // style of the placeholder: braces, dollar, var or a custom regex
+	Name    string      `json:"name"`    // variable name
+	Value   interface{} `json:"value"`   // resolved value
+	Old     string      `json:"old"`     // placeholder as written, e.g. {{StatusOK}}
+	New     string      `json:"new"`     // text it was replaced with
+	File    string      `json:"-"`       // file of the comment, already that of its Result
+	Line    int         `json:"line"`
+	// Definition is the file:line the variable was defined at, if known
+	Definition string `json:"definition,omitempty"`
+}
+
+// addUnresolved records a variable that couldn't be found on a line
+func (res *Result) addUnresolved(name, placeholder string, line, col int) {
+	seen := false
+	for _, u := range res.missing {
+		seen = seen || u.name == name
+	}
+	if !seen {
+		res.Unresolved = append(res.Unresolved, name)
+	}
+	res.missing = append(res.missing, unresolvedVar{name: name, placeholder: placeholder, file: res.File, line: line, col: col})
+}
+
+// unresolvedVar is a placeholder whose variable couldn't be found
+type unresolvedVar struct {
+	name        string
+	placeholder string // as written, e.g. {{Name}}
+	file        string
+	line        int
+	col         int
+}
+
+// pattern is a placeholder syntax whose first capture group is the
+// variable name
+type pattern struct {
+	style string // braces, dollar, var, or the expression of a custom pattern
+	re    *regexp.Regexp
+}
+
+// namePattern matches a variable name, optionally qualified by a package
+// name as in pkg.Name, or naming a field or map key as in Defaults.a.b, or
+// an element of a slice as in Routes.0
+const namePattern = `[A-Za-z_][A-Za-z0-9_]*(?:\.(?:[A-Za-z_][A-Za-z0-9_]*|[0-9]+))*`
+
+// compilePattern compiles a custom placeholder expression, which must have
+// exactly one capture group for the variable name
+func compilePattern(expr string) (pattern, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return pattern{}, fmt.Errorf("invalid pattern %q: %v", expr, err)
+	}
+	if re.NumSubexp() != 1 {
+		return pattern{}, fmt.Errorf("invalid pattern %q: must have exactly one capture group", expr)
+	}
+	return pattern{style: expr, re: re}, nil
+}
+
+// AddPattern registers an extra placeholder syntax, such as `%\((\w+)\)s`,
+// alongside the existing ones. The expression must have exactly one capture
+// group, matching the variable name.
+func (r *SwaggerVariableReplacer) AddPattern(expr string) error {
+	p, err := compilePattern(expr)
+	if err != nil {
+		return err
+	}
+	r.patterns = append(r.patterns, p)
+	return nil
+}
+
+// SetPatterns replaces every placeholder syntax, including the defaults,
+// with exprs. Each expression must have exactly one capture group; if any
+// is invalid, the patterns are left unchanged.
+func (r *SwaggerVariableReplacer) SetPatterns(exprs []string) error {
+	patterns := make([]pattern, 0, len(exprs))
+	for _, expr := range exprs {
+		p, err := compilePattern(expr)
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, p)
+	}
+	r.patterns = patterns
+	return nil
+}
+
+// builtinStyles are the styles of the default patterns
+var builtinStyles = []string{"braces", "dollar", "var"}
+
+// isBuiltin reports whether style is the style of a default pattern
+func isBuiltin(style string) bool {
+	for _, builtin := range builtinStyles {
+		if style == builtin {
+			return true
+		}
+	}
+	return false
+}
+
+// SetBuiltinPatterns keeps only the default patterns whose style is listed
+// in styles, among braces ({{Name}}), dollar (${Name}) and var
+// (@VAR(Name)), leaving custom patterns active
+func (r *SwaggerVariableReplacer) SetBuiltinPatterns(styles []string) error {
+	enabled := make(map[string]bool)
+	for _, style := range styles {
+		if !isBuiltin(style) {
+			return fmt.Errorf("unknown pattern %q (want %s)", style, strings.Join(builtinStyles, ", "))
+		}
+		enabled[style] = true
+	}
+
+	patterns := r.patterns[:0]
+	for _, p := range r.patterns {
+		if !isBuiltin(p.style) || enabled[p.style] {
+			patterns = append(patterns, p)
+		}
+	}
+	r.patterns = patterns
+	return nil
+}
+
+// NewSwaggerVariableReplacer creates a new replacer instance
+func NewSwaggerVariableReplacer() *SwaggerVariableReplacer {
+	return &SwaggerVariableReplacer{
+		constants:       make(map[string]ConstantInfo),
+		fileConstants:   make(map[string]map[string]ConstantInfo),
+		configConstants: make(map[string]ConstantInfo),
+		imports:         make(map[string]fileImports),
+		stringNames:     make(map[string]string),
+		maxUnresolved:   -1,
+		maxDepth:        -1,
+		backupSuffix:    ".backup",
+		boolTrue:        "true",
+		boolFalse:       "false",
+		warningFormat:   "prose",
+		failed:          make(map[string]bool),
+		patterns: []pattern{
+			// Pattern 1: {{VariableName}} or {{ VariableName }}, optionally
+			// with a format as in {{VariableName:upper}}
+			{"braces", regexp.MustCompile(`\{\{\s*(` + namePattern + `)(?:\s*:\s*([A-Za-z_][A-Za-z0-9_]*))?\s*\}\}`)},
+			// Pattern 2: ${VariableName} or ${ VariableName }
+			{"dollar", regexp.MustCompile(`\$\{\s*(` + namePattern + `)\s*\}`)},
+			// Pattern 3: @VAR(VariableName) or @VAR(VariableName, "default"),
+			// also with spaces inside the parentheses
+			{"var", regexp.MustCompile(`@VAR\(\s*(` + namePattern + `)(?:\s*,\s*("(?:[^"\\]|\\.)*"))?\s*\)`)},
+		},
+	}
+}
+
+// ProcessDirectory processes all Go files in a directory. Constants from
+// every file are extracted first, so placeholders resolve across files. A
+// file that can't be parsed or written is reported and skipped, and an
+// error is returned once all the others are processed; in strict mode, the
+// first failure stops the run.
+func (r *SwaggerVariableReplacer) ProcessDirectory(dir string) error {
+	return r.ProcessDirectoryContext(context.Background(), dir)
+}
+
+// ProcessDirectoryContext is like ProcessDirectory, but stops walking and
+// returns ctx's error as soon as ctx is done. Since files are written
+// atomically, every file is left either fully substituted or untouched.
+func (r *SwaggerVariableReplacer) ProcessDirectoryContext(ctx context.Context, dir string) error {
+	r.failed = make(map[string]bool)
+	r.readOnly = nil
+	fromConflicts := len(r.conflicts)
+	if err := r.extractDir(ctx, dir); err != nil {
+		return err
+	}
+	if err := r.conflictError(fromConflicts); err != nil {
+		return err
+	}
+
+	from, fromResults, fromMalformed := len(r.unresolved), len(r.results), len(r.malformed)
+	if err := r.replaceDir(ctx, dir); err != nil {
+		return err
+	}
+
+	r.warnUnresolved(from)
+	r.printSummary(fromResults, from)
+	if err := r.failedError(); err != nil {
+		return err
+	}
+	return errors.Join(r.unresolvedError(from), r.malformedError(fromMalformed))
+}
+
+// replaceDir replaces variables in every Go file under dir, except those
+// that already failed
+func (r *SwaggerVariableReplacer) replaceDir(ctx context.Context, dir string) error {
+	return r.forEachGoFile(ctx, dir, "Replacing", r.processTests, func(path string) error {
+		r.mu.Lock()
+		skip := r.failed[path]
+		r.mu.Unlock()
+		if skip {
+			return nil
+		}
+
+		r.logf("Processing: %s\n", path)
+		if _, err := r.replaceVariablesInComments(path); err != nil {
+			return r.pathFailed(path, err)
+		}
+		return nil
+	})
+}
+
+// printSummary prints the number of files processed, substitutions made and
+// variables left unresolved since the given indexes, except in check mode
+func (r *SwaggerVariableReplacer) printSummary(fromResults, fromUnresolved int) {
+	if r.check {
+		return
+	}
+	if len(r.readOnly) > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %d read-only file(s): %s\n", len(r.readOnly), strings.Join(r.readOnly, ", "))
+	}
+	if r.quiet {
+		return
+	}
+	substitutions := 0
+	for _, res := range r.results[fromResults:] {
+		substitutions += len(res.Substitutions)
+	}
+	fmt.Fprintf(os.Stderr, "Processed %d file(s), %d substitution(s), %d unresolved\n",
+		len(r.results)-fromResults, substitutions, len(r.unresolved)-fromUnresolved)
+}
+
+// ExtractFromDir adds the constants of every Go file under dir to the
+// replacer's table without modifying anything. Together with ReplaceInFile
+// it lets callers build the table once and apply it to any set of files.
+// In strict mode, constants defined differently in two files are an error.
+// Files that can't be parsed are reported and skipped like in
+// ProcessDirectory.
+func (r *SwaggerVariableReplacer) ExtractFromDir(dir string) error {
+	r.failed = make(map[string]bool)
+	from := len(r.conflicts)
+	if err := r.extractDir(context.Background(), dir); err != nil {
+		return err
+	}
+	if err := r.conflictError(from); err != nil {
+		return err
+	}
+	return r.failedError()
+}
+
+// extractDir adds the constants of every Go file under dir to the table,
+// including test files with SetIncludeTests, skipping files that fail to
+// parse unless in strict mode
+func (r *SwaggerVariableReplacer) extractDir(ctx context.Context, dir string) error {
+	err := r.forEachGoFile(ctx, dir, "Extracting", r.includeTests, func(path string) error {
+		r.logf("Processing: %s\n", path)
+		if err := r.extractConstants(path); err != nil {
+			return r.pathFailed(path, err)
+		}
+		return nil
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extract constants: %s", err.Error())
+	}
+	return nil
+}
+
+// ExtractFromFile adds the constants of a single Go file to the replacer's
+// table without modifying anything
+func (r *SwaggerVariableReplacer) ExtractFromFile(filename string) error {
+	if err := r.extractConstants(filename); err != nil {
+		return fmt.Errorf("failed to extract constants from %s: %v", filename, err)
+	}
+	return nil
+}
+
+// ReplaceInFile replaces variables in the comments of a single file using
+// the constants extracted so far, without extracting from the file itself
+func (r *SwaggerVariableReplacer) ReplaceInFile(filename string) (*Result, error) {
+	res, err := r.replaceVariablesInComments(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replace variables in %s: %v", filename, err)
+	}
+	return res, nil
+}
+
+// ProcessFile processes a single Go file
+func (r *SwaggerVariableReplacer) ProcessFile(filename string) (*Result, error) {
+	// Step 1: Parse the file to extract constants
+	if err := r.extractConstants(filename); err != nil {
+		return nil, fmt.Errorf("failed to extract constants from %s: %v", filename, err)
+	}
+
+	// Step 2: Process comments and replace variables
+	from, fromMalformed := len(r.unresolved), len(r.malformed)
+	res, err := r.replaceVariablesInComments(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replace variables in %s: %v", filename, err)
+	}
+
+	r.warnUnresolved(from)
+	return res, errors.Join(r.unresolvedError(from), r.malformedError(fromMalformed))
+}
+
+// ProcessReader reads Go source from in, extracts its constants, and writes
+// the source with variables in comments replaced to out. Nothing on disk
+// is touched.
+func (r *SwaggerVariableReplacer) ProcessReader(in io.Reader, out io.Writer) error {
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return fmt.Errorf("failed to read source: %v", err)
+	}
+
+	newContent, err := r.processSource("<stdin>", src)
+	if newContent != nil {
+		if _, err := out.Write(newContent); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// sourceName is the file name reported for in-memory sources
+const sourceName = "<source>"
+
+// ExtractConstantsFromSource adds the constants of Go source held in memory
+// to the replacer's table
+func (r *SwaggerVariableReplacer) ExtractConstantsFromSource(src []byte) error {
+	if err := r.extractConstantsFromSource(sourceName, src); err != nil {
+		return fmt.Errorf("failed to extract constants from %s: %v", sourceName, err)
+	}
+	return nil
+}
+
+// ProcessSource returns Go source held in memory with variables in comments
+// replaced, without touching the filesystem. Constants are extracted from
+// src itself, in addition to those already in the table and constants.
+// The substituted source is returned along with any strict mode error.
+func (r *SwaggerVariableReplacer) ProcessSource(constants map[string]interface{}, src []byte) ([]byte, error) {
+	for name, value := range constants {
+		r.constants[name] = ConstantInfo{Value: value}
+	}
+	return r.processSource(sourceName, src)
+}
+
+// processSource extracts the constants of src and substitutes them, using
+// name for diagnostics
+func (r *SwaggerVariableReplacer) processSource(name string, src []byte) (_ []byte, err error) {
+	defer recoverFile(name, &err)
+	if err := r.extractConstantsFromSource(name, src); err != nil {
+		return nil, fmt.Errorf("failed to extract constants from %s: %v", name, err)
+	}
+
+	from, fromMalformed := len(r.unresolved), len(r.malformed)
+	newContent, res := r.substituteSource(name, src)
+	if err := r.invalidError(res); err != nil {
+		return nil, err
+	}
+	r.record(res)
+	if res.LinesChanged > 0 {
+		formatted, err := r.gofmtSource(name, newContent)
+		if err != nil {
+			return nil, err
+		}
+		newContent = formatted
+	}
+	r.warnUnresolved(from)
+	return []byte(newContent), errors.Join(r.unresolvedError(from), r.malformedError(fromMalformed))
+}
+
+// DryRun extracts constants from a single Go file and prints the pending
+// substitutions as a unified diff without writing. It returns the number
+// of lines that would change.
+func (r *SwaggerVariableReplacer) DryRun(filename string) (int, error) {
+	if err := r.extractConstants(filename); err != nil {
+		return 0, fmt.Errorf("failed to extract constants from %s: %v", filename, err)
+	}
+	res, err := r.previewFile(filename)
+	if err != nil {
+		return 0, err
+	}
+	return res.LinesChanged, nil
+}
+
+// DryRunDirectory processes dir like ProcessDirectory, printing the pending
+// substitutions of every file as a unified diff without writing. It returns
+// the number of lines that would change across all files.
+func (r *SwaggerVariableReplacer) DryRunDirectory(dir string) (int, error) {
+	dryRun := r.dryRun
+	r.dryRun = true
+	defer func() { r.dryRun = dryRun }()
+
+	from := len(r.results)
+	err := r.ProcessDirectory(dir)
+	lines := 0
+	for _, res := range r.results[from:] {
+		lines += res.LinesChanged
+	}
+	return lines, err
+}
+
+// ResolvedComments extracts constants from file and returns the text of
+// each of its comment lines with variables substituted, keyed by line
+// number, without writing anything. The text runs from the first comment
+// on the line to the end of the last one, and a multiline value spans
+// several lines of it.
+func (r *SwaggerVariableReplacer) ResolvedComments(file string) (map[int]string, error) {
+	if err := r.extractConstants(file); err != nil {
+		return nil, fmt.Errorf("failed to extract constants from %s: %v", file, err)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	_, res, err := r.substituteFile(file)
+	if err != nil {
+		return nil, err
+	}
+	changed := make(map[int]string, len(res.changes))
+	for _, change := range res.changes {
+		changed[change.line] = strings.ReplaceAll(change.newText, "\r\n", "\n")
+	}
+
+	comments := make(map[int]string)
+	var scanner commentScanner
+	content, _ = bytes.CutPrefix(content, utf8BOM)
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		spans := scanner.commentSpans(line)
+		if len(spans) == 0 {
+			continue
+		}
+		// Only comments change, so the code around them keeps its length
+		after := len(line) - spans[len(spans)-1].end
+		if text, ok := changed[i+1]; ok {
+			line = strings.TrimSuffix(text, "\r")
+		}
+		comments[i+1] = line[spans[0].start : len(line)-after]
+	}
+	return comments, nil
+}
+
+// SetDryRun routes ProcessFile and ProcessDirectory through the dry-run path
+func (r *SwaggerVariableReplacer) SetDryRun(enabled bool) {
+	r.dryRun = enabled
+}
+
+// SetCheck makes ProcessFile and ProcessDirectory compute pending changes
+// without writing anything or printing a diff, as in gofmt -l. Files that
+// would change are those whose Result has LinesChanged > 0.
+func (r *SwaggerVariableReplacer) SetCheck(enabled bool) {
+	r.check = enabled
+}
+
+// SetBackup makes ProcessFile and ProcessDirectory back up each file to
+// <name>.backup before modifying it. Unchanged files are not backed up.
+func (r *SwaggerVariableReplacer) SetBackup(enabled bool) {
+	r.backup = enabled
+}
+
+// SetBackupSuffix sets the suffix appended to file names for backups,
+// ".backup" by default
+func (r *SwaggerVariableReplacer) SetBackupSuffix(suffix string) {
+	r.backupSuffix = suffix
+}
+
+// SetBackupDir makes backups go under dir, at the path of each file
+// relative to the working directory, instead of next to the file
+func (r *SwaggerVariableReplacer) SetBackupDir(dir string) {
+	r.backupDir = dir
+}
+
+// SetStrict makes ProcessFile and ProcessDirectory return an error listing
+// every variable they couldn't resolve, along with any placeholder flagged
+// by SetLintPlaceholders. Files are still processed.
+func (r *SwaggerVariableReplacer) SetStrict(enabled bool) {
+	r.strict = enabled
+}
+
+// Errors returned when a run fails the strict mode or SetMaxUnresolved
+// checks, or skips a read-only file, match these with errors.Is, telling
+// them apart from I/O and parse errors
+var (
+	ErrUnresolved = errors.New("unresolved variables")
+	ErrConflict   = errors.New("conflicting constants")
+	ErrMalformed  = errors.New("malformed placeholders")
+	ErrReadOnly   = errors.New("read-only file")
+)
+
+// checkError is a failed check, detailed by msg, matching kind
+type checkError struct {
+	kind error
+	msg  string
+}
+
+func (e *checkError) Error() string { return e.msg }
+func (e *checkError) Unwrap() error { return e.kind }
+
+// unresolvedError returns an error listing each unresolved variable recorded
+// since index from once, with every location it was referenced at, or nil
+// if there were none or strict mode is off. Outside strict mode, it only
+// returns an error if there are more than the maximum set by
+// SetMaxUnresolved.
+func (r *SwaggerVariableReplacer) unresolvedError(from int) error {
+	count := len(r.unresolved) - from
+	if !r.strict {
+		if r.maxUnresolved >= 0 && count > r.maxUnresolved {
+			return &checkError{ErrUnresolved, fmt.Sprintf("%d unresolved variable(s), more than the maximum of %d", count, r.maxUnresolved)}
+		}
+		return nil
+	}
+	if count <= 0 {
+		return nil
+	}
+
+	var names []string
+	locations := make(map[string][]string)
+	for _, u := range r.unresolved[from:] {
+		if _, seen := locations[u.name]; !seen {
+			names = append(names, u.name)
+		}
+		locations[u.name] = append(locations[u.name], fmt.Sprintf("%s:%d", u.file, u.line))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d unresolved variable(s):", len(names))
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n  %s (%s)", name, strings.Join(locations[name], ", "))
+	}
+	return &checkError{ErrUnresolved, b.String()}
+}
+
+// warnUnresolved prints a single warning for each variable left unresolved
+// since the given index, with the number of placeholders referencing it and
+// the first of them. Nothing is printed in strict mode, whose error lists
+// them all, or in verbose mode unless quiet, as it warns about every
+// placeholder when found.
+func (r *SwaggerVariableReplacer) warnUnresolved(from int) {
+	if r.strict {
+		return
+	}
+	if r.warningFormat == "gcc" {
+		for _, u := range r.unresolved[from:] {
+			r.gccWarning(u.file, u.line, u.col, "unresolved variable '%s'", u.name)
+		}
+		return
+	}
+	if r.verbose && !r.quiet {
+		return
+	}
+	var names []string
+	first := make(map[string]unresolvedVar)
+	counts := make(map[string]int)
+	for _, u := range r.unresolved[from:] {
+		if counts[u.name] == 0 {
+			names = append(names, u.name)
+			first[u.name] = u
+		}
+		counts[u.name]++
+	}
+	for _, name := range names {
+		u := first[name]
+		fmt.Fprintf(os.Stderr, "Warning: Variable '%s' not found (%d occurrence(s), first at %s:%d)\n", name, counts[name], u.file, u.line)
+	}
+}
+
+// SetBuildTags makes directory processing skip files whose build
+// constraints aren't satisfied for the current GOOS and GOARCH with tags
+// set, the way `go build -tags` would. Without it, every Go file is
+// processed regardless of its constraints.
+func (r *SwaggerVariableReplacer) SetBuildTags(tags []string) {
+	ctx := build.Default
+	ctx.BuildTags = tags
+	r.buildContext = &ctx
+}
+
+// SetFollowSymlinks makes directory processing walk into symlinked
+// directories, which are skipped by default. Links leading back to a
+// directory already walked are skipped either way.
+func (r *SwaggerVariableReplacer) SetFollowSymlinks(enabled bool) {
+	r.followSymlinks = enabled
+}
+
+// SetWriteReadOnly makes substitution rewrite read-only files, keeping
+// them read-only, instead of reporting and skipping them
+func (r *SwaggerVariableReplacer) SetWriteReadOnly(enabled bool) {
+	r.writeReadOnly = enabled
+}
+
+// SetIncludeTests makes directory processing extract constants from
+// _test.go files too, so that comments elsewhere can reference them. Test
+// files are still only substituted with SetProcessTests.
+func (r *SwaggerVariableReplacer) SetIncludeTests(enabled bool) {
+	r.includeTests = enabled
+}
+
+// SetProcessTests makes directory processing substitute placeholders in
+// _test.go files too, such as those of Example doc comments. Constants are
+// only extracted from test files with SetIncludeTests.
+func (r *SwaggerVariableReplacer) SetProcessTests(enabled bool) {
+	r.processTests = enabled
+}
+
+// SetIncludeGenerated makes directory processing include files marked with
+// a "// Code generated ... DO NOT EDIT." header, which are skipped by default
+func (r *SwaggerVariableReplacer) SetIncludeGenerated(enabled bool) {
+	r.includeGenerated = enabled
+}
+
+// SetMaxDepth limits directory processing to depth levels of directories
+// below the root: 0 processes only the files directly in it. A negative
+// depth, the default, means no limit.
+func (r *SwaggerVariableReplacer) SetMaxDepth(depth int) {
+	r.maxDepth = depth
+}
+
+// SetMaxUnresolved makes a run fail once more than max variable references
+// are left unresolved, counted across all files and patterns. A negative
+// max, the default, means no limit.
+func (r *SwaggerVariableReplacer) SetMaxUnresolved(max int) {
+	r.maxUnresolved = max
+}
+
+// SetAlign makes the replacer re-align the columns of comment blocks whose
+// lines changed length through substitution. Columns are separated by two
+// or more spaces.
+func (r *SwaggerVariableReplacer) SetAlign(enabled bool) {
+	r.align = enabled
+}
+
+// SetJobs sets how many files ProcessDirectory and ExtractFromDir process
+// concurrently. Values below 2 process files one at a time.
+func (r *SwaggerVariableReplacer) SetJobs(n int) {
+	r.jobs = n
+}
+
+// SetFloatFormat sets the fmt format, such as "%g" or "%.2f", used to
+// substitute float values. By default floats are substituted as written in
+// the source, or with %v when that isn't available.
+func (r *SwaggerVariableReplacer) SetFloatFormat(format string) {
+	r.floatFormat = format
+}
+
+// SetGofmt makes every file changed by substitution go through go/format
+// before it is written, which also reformats doc comments. Files that no
+// longer parse after substitution are reported as errors.
+func (r *SwaggerVariableReplacer) SetGofmt(enabled bool) {
+	r.gofmt = enabled
+}
+
+// SetReplaceOnce makes substitution replace only the first occurrence of
+// each placeholder in a comment line, leaving its repeats, such as literal
+// examples, as written. Other placeholders on the line are substituted as
+// usual, so a line without repeats doesn't change on later runs.
+func (r *SwaggerVariableReplacer) SetReplaceOnce(enabled bool) {
+	r.replaceOnce = enabled
+}
+
+// SetMarkUnresolved makes substitution append a comment such as
+// "// TODO: unresolved {{Missing}}" to each comment line whose placeholders
+// can't all be resolved, so that they stand out in review. The marker is
+// refreshed rather than repeated on later runs, and dropped once every
+// placeholder of the line resolves. Strict mode never marks lines.
+func (r *SwaggerVariableReplacer) SetMarkUnresolved(enabled bool) {
+	r.markUnresolved = enabled
+}
+
+// SetRecursiveResolve makes substitution resolve placeholders inside the
+// values it inserts, such as the {{Inner}} of const Template = "{{Inner}}",
+// up to depth levels deep. A variable whose value leads back to itself is
+// reported and left as written. With depth 0, the default, values are
+// inserted literally.
+func (r *SwaggerVariableReplacer) SetRecursiveResolve(depth int) {
+	r.recursiveDepth = depth
+}
+
+// SetBoolStrings sets the text substituted for bool values, such as "yes"
+// and "no"; by default they substitute as true and false
+func (r *SwaggerVariableReplacer) SetBoolStrings(trueText, falseText string) {
+	r.boolTrue, r.boolFalse = trueText, falseText
+}
+
+// SetValidator sets a function checking every substitution, such as that
+// a status code is a valid HTTP status. A substitution it rejects is
+// reported as a warning, or, in strict mode, keeps the file from being
+// written and fails processing with the error. A nil validator accepts
+// every substitution.
+func (r *SwaggerVariableReplacer) SetValidator(validator func(sub Substitution) error) {
+	r.validator = validator
+}
+
+// SetFormatter sets the function rendering every resolved value into the
+// comment, given the variable name and its value. Values from the config
+// constant map, environment and @VAR defaults are strings. A nil formatter
+// restores the built-in formatting.
+func (r *SwaggerVariableReplacer) SetFormatter(formatter func(name string, value interface{}) string) {
+	r.formatter = formatter
+}
+
+// SetStringer makes constants of a type with a String method substitute as
+// what that method returns, such as OK rather than 200. Only String methods
+// made of a switch over the receiver returning string literals are
+// understood; other constants substitute as usual.
+func (r *SwaggerVariableReplacer) SetStringer(enabled bool) {
+	r.stringer = enabled
+}
+
+// SetRuneCodes makes rune constants substitute as their numeric code
+// point, such as 47, instead of the character itself, such as /
+func (r *SwaggerVariableReplacer) SetRuneCodes(enabled bool) {
+	r.runeCodes = enabled
+}
+
+// SetProgress makes directory processing keep a count of the files
+// extracted and replaced so far, such as "Replacing 450/2000", updated in
+// place on a single line of w. A nil w disables it.
+func (r *SwaggerVariableReplacer) SetProgress(w io.Writer) {
+	r.progress = w
+}
+
+// SetColor makes dry runs print removed lines in red and added lines in
+// green, for output to a terminal
+func (r *SwaggerVariableReplacer) SetColor(enabled bool) {
+	r.color = enabled
+}
+
+// SetSummary makes dry runs print, for each file that would change, just
+// its name and every changed line as "before -> after", instead of a
+// unified diff. Line numbers are only shown in verbose mode.
+func (r *SwaggerVariableReplacer) SetSummary(enabled bool) {
+	r.summary = enabled
+}
+
+// SetQuiet suppresses the informational messages of the replacer, such as
+// the summary of a run and those of verbose mode. Warnings still print.
+func (r *SwaggerVariableReplacer) SetQuiet(enabled bool) {
+	r.quiet = enabled
+}
+
+// SetWarningFormat sets how warnings about a location in a file print:
+// "prose", the default, or "gcc" for file:line:col: message lines, one
+// per occurrence, that editors and CI tools can parse
+func (r *SwaggerVariableReplacer) SetWarningFormat(format string) error {
+	if format != "prose" && format != "gcc" {
+		return fmt.Errorf("unknown warning format %q, expected prose or gcc", format)
+	}
+	r.warningFormat = format
+	return nil
+}
+
+// gccWarning prints a warning about file:line:col in the gcc format and
+// returns true if that is the warning format, or returns false so that the
+// caller prints its prose warning instead
+func (r *SwaggerVariableReplacer) gccWarning(file string, line, col int, format string, args ...interface{}) bool {
+	if r.warningFormat != "gcc" {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", file, line, col, fmt.Sprintf(format, args...))
+	return true
+}
+
+// SetVerbose makes the replacer log each processed file and replaced line
+func (r *SwaggerVariableReplacer) SetVerbose(enabled bool) {
+	r.verbose = enabled
+}
+
+// logf prints an informational message to stderr in verbose mode, unless
+// quiet
+func (r *SwaggerVariableReplacer) logf(format string, args ...interface{}) {
+	if r.verbose && !r.quiet {
+		fmt.Fprintf(os.Stderr, format, args...)
+	}
+}
+
+// record adds the result of processing a file
+func (r *SwaggerVariableReplacer) record(res *Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, res)
+	if r.dryRun || r.check {
+		r.pending += res.LinesChanged
+	}
+}
+
+// Results returns the result of every file processed so far, in order
+func (r *SwaggerVariableReplacer) Results() []*Result {
+	return r.results
+}
+
+// SetPreferLocal makes placeholders resolve against the constants of the
+// file they appear in before the table shared by all files, so that a
+// name defined in several files takes the file's own value
+func (r *SwaggerVariableReplacer) SetPreferLocal(enabled bool) {
+	r.preferLocal = enabled
+}
+
+// SetFileScope makes placeholders resolve only against the constants of the
+// file they appear in, so constants never leak between files processed
+// together. Constants that don't come from a file, such as those from
+// AddPackage or a config's constant map, remain visible everywhere.
+func (r *SwaggerVariableReplacer) SetFileScope(enabled bool) {
+	r.fileScope = enabled
+}
+
+// SetOverride makes the constant map of a config take precedence over
+// constants extracted from source. By default extracted constants win and
+// the constant map only provides values for names found nowhere else.
+func (r *SwaggerVariableReplacer) SetOverride(enabled bool) {
+	r.override = enabled
+}
+
+// SetEnv makes variables that aren't defined in source, nor in the constant
+// map of a config, resolve from environment variables of the same name, as
+// in {{BUILD_SHA}}
+func (r *SwaggerVariableReplacer) SetEnv(enabled bool) {
+	r.env = enabled
+}
+
+// outOfRange reports whether name, unresolved in file, indexes past the end
+// of a known slice or array, as Routes.5 does if Routes has fewer elements,
+// returning the slice and the index
+func (r *SwaggerVariableReplacer) outOfRange(file, name string) (string, string, bool) {
+	dot := strings.LastIndex(name, ".")
+	if dot < 0 {
+		return "", "", false
+	}
+	list, index := name[:dot], name[dot+1:]
+	if _, err := strconv.Atoi(index); err != nil {
+		return "", "", false
+	}
+	_, known := r.lookup(file, list+".0")
+	return list, index, known
+}
+
+// lookup resolves a variable referenced in file. Source constants come
+// first, then constants of packages the file imports, then the constant map
+// of a config, then the environment; the constant map comes first instead
+// with SetOverride.
+func (r *SwaggerVariableReplacer) lookup(file, name string) (ConstantInfo, bool) {
+	if info, exists := r.configConstants[name]; exists && r.override {
+		return info, true
+	}
+	if info, exists := r.lookupSource(file, name); exists {
+		return info, true
+	}
+	if info, exists := r.lookupImport(file, name); exists {
+		return info, true
+	}
+	if info, exists := r.configConstants[name]; exists {
+		return info, true
+	}
+	if r.env {
+		if value, set := os.LookupEnv(name); set {
+			return ConstantInfo{Value: value}, true
+		}
+	}
+	return ConstantInfo{}, false
+}
+
+// lookupSource resolves a variable referenced in file from the constants
+// extracted from source or added as packages
+func (r *SwaggerVariableReplacer) lookupSource(file, name string) (ConstantInfo, bool) {
+	if r.fileScope || r.preferLocal {
+		if info, exists := r.fileConstants[file][name]; exists {
+			return info, true
+		}
+	}
+	info, exists := r.constants[name]
+	if exists && r.fileScope {
+		// Constants extracted from other files are out of scope
+		if _, extracted := r.fileConstants[info.File][name]; extracted {
+			return ConstantInfo{}, false
+		}
+	}
+	return info, exists
+}
+
+// Constants returns every constant known to the replacer, sorted by name.
+// Where the constant map of a config and source define the same name, only
+// the one taking precedence is returned.
+func (r *SwaggerVariableReplacer) Constants() []Constant {
+	table := make(map[string]ConstantInfo, len(r.constants)+len(r.configConstants))
+	for name, info := range r.constants {
+		table[name] = info
+	}
+	for name, info := range r.configConstants {
+		if _, exists := table[name]; r.override || !exists {
+			table[name] = info
+		}
+	}
+
+	constants := make([]Constant, 0, len(table))
+	for name, info := range table {
+		constants = append(constants, Constant{
+			Name:     name,
+			Value:    info.Value,
+			Type:     typeName(info.Value),
+			Location: info.Location(),
+		})
+	}
+	sort.Slice(constants, func(i, j int) bool {
+		return constants[i].Name < constants[j].Name
+	})
+	return constants
+}
+
+// typeName returns the Go type of a constant's value
+func typeName(value interface{}) string {
+	if _, isRune := value.(rune); isRune {
+		return "rune"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// Conflicts returns the constants extracted with different values from
+// different files
+func (r *SwaggerVariableReplacer) Conflicts() []Conflict {
+	return r.conflicts
+}
+
+// conflictError returns an error naming both locations of each conflict
+// recorded since index from, or nil if there were none or strict mode is off
+func (r *SwaggerVariableReplacer) conflictError(from int) error {
+	if !r.strict || len(r.conflicts) <= from {
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d conflicting constant(s):", len(r.conflicts)-from)
+	for _, c := range r.conflicts[from:] {
+		fmt.Fprintf(&b, "\n  %s is %v at %s but %v at %s", c.Name, c.Value, c.Location, c.OtherValue, c.OtherLocation)
+	}
+	return &checkError{ErrConflict, b.String()}
+}
+
+// Pending returns the number of lines that would change, as counted by dry
+// runs and checks
+func (r *SwaggerVariableReplacer) Pending() int {
+	return r.pending
+}
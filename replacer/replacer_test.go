@@ -0,0 +1,504 @@
+package replacer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractFromDirThenReplaceInFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\nconst StatusOK = 200\n")
+	b := writeTestFile(t, dir, "b.go", "package api\n\n// @Success {{StatusOK}}\n")
+	c := writeTestFile(t, dir, "c.go", "package api\n\n// {{StatusOK}}\n")
+
+	r := NewSwaggerVariableReplacer()
+	if err := r.ExtractFromDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	res, err := r.ReplaceInFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.LinesChanged != 1 {
+		t.Errorf("LinesChanged = %d, want 1", res.LinesChanged)
+	}
+	if got, want := readTestFile(t, b), "package api\n\n// @Success 200\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := readTestFile(t, c), "package api\n\n// {{StatusOK}}\n"; got != want {
+		t.Errorf("file outside the subset modified: %q", got)
+	}
+}
+
+func TestStrictListsEachVariableOnce(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n\n// {{A}} {{Missing}}\n// {{Missing}} ${Other}\n")
+	r := NewSwaggerVariableReplacer()
+	r.SetStrict(true)
+	err := r.ProcessDirectory(dir)
+	if !errors.Is(err, ErrUnresolved) {
+		t.Fatalf("err = %v, want ErrUnresolved", err)
+	}
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "2 unresolved variable(s):") {
+		t.Errorf("unexpected count in %q", msg)
+	}
+	for _, name := range []string{"Missing", "Other"} {
+		if n := strings.Count(msg, "\n  "+name+" ("); n != 1 {
+			t.Errorf("%s listed %d times in %q", name, n, msg)
+		}
+	}
+	if !strings.Contains(msg, "a.go:5, ") || !strings.Contains(msg, "a.go:6)") {
+		t.Errorf("locations missing from %q", msg)
+	}
+
+	// Outside strict mode the placeholders are only warned about
+	r = NewSwaggerVariableReplacer()
+	b := writeTestFile(t, dir, "b.go", "package api\n\n// {{Missing}}\n")
+	stderr := capture(t, &os.Stderr, func() { _, err = r.ProcessFile(b) })
+	if err != nil {
+		t.Errorf("non-strict run failed: %v", err)
+	}
+	if !strings.Contains(stderr, "Warning: Variable 'Missing' not found") {
+		t.Errorf("no warning in %q", stderr)
+	}
+}
+
+func TestProcessReader(t *testing.T) {
+	src := "package api\n\nconst A = \"a\"\n\n// {{A}} ${A}\n"
+	var out bytes.Buffer
+	if err := NewSwaggerVariableReplacer().ProcessReader(strings.NewReader(src), &out); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "package api\n\nconst A = \"a\"\n\n// a a\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if err := NewSwaggerVariableReplacer().ProcessReader(strings.NewReader("not go"), &out); err == nil {
+		t.Error("invalid source accepted")
+	}
+}
+
+func TestProcessFileResult(t *testing.T) {
+	path := writeTestFile(t, t.TempDir(), "a.go", `package api
+
+const StatusOK = 200
+
+// @Success {{StatusOK}}
+// @Failure ${Missing} {{Missing}}
+// @Param @VAR(StatusOK)
+`)
+	res, err := NewSwaggerVariableReplacer().ProcessFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.File != path {
+		t.Errorf("File = %q, want %q", res.File, path)
+	}
+	if res.LinesChanged != 2 {
+		t.Errorf("LinesChanged = %d, want 2", res.LinesChanged)
+	}
+	want := []Substitution{
+		{Pattern: "braces", Name: "StatusOK", Value: 200, Old: "{{StatusOK}}", New: "200", Line: 5},
+		{Pattern: "var", Name: "StatusOK", Value: 200, Old: "@VAR(StatusOK)", New: "200", Line: 7},
+	}
+	if len(res.Substitutions) != len(want) {
+		t.Fatalf("Substitutions = %+v, want %+v", res.Substitutions, want)
+	}
+	for i, sub := range res.Substitutions {
+		w := want[i]
+		if sub.Pattern != w.Pattern || sub.Name != w.Name || sub.Value != w.Value || sub.Old != w.Old || sub.New != w.New || sub.Line != w.Line {
+			t.Errorf("Substitutions[%d] = %+v, want %+v", i, sub, w)
+		}
+	}
+	if len(res.Unresolved) != 1 || res.Unresolved[0] != "Missing" {
+		t.Errorf("Unresolved = %v, want [Missing]", res.Unresolved)
+	}
+}
+
+func TestVerboseLogging(t *testing.T) {
+	for _, verbose := range []bool{false, true} {
+		path := writeTestFile(t, t.TempDir(), "a.go", "package api\n\nconst A = 1\n\n// {{A}}\n")
+		r := NewSwaggerVariableReplacer()
+		r.SetVerbose(verbose)
+		var stdout string
+		stderr := capture(t, &os.Stderr, func() {
+			stdout = capture(t, &os.Stdout, func() {
+				if _, err := r.ProcessFile(path); err != nil {
+					t.Fatal(err)
+				}
+			})
+		})
+		if stdout != "" {
+			t.Errorf("verbose %v: printed to stdout: %q", verbose, stdout)
+		}
+		if logged := stderr != ""; logged != verbose {
+			t.Errorf("verbose %v: stderr %q", verbose, stderr)
+		}
+	}
+}
+
+// writePackage writes a package of n files under dir, each defining a
+// constant and referencing those of its neighbours
+func writePackage(tb testing.TB, dir string, n int) {
+	for i := 0; i < n; i++ {
+		src := fmt.Sprintf("package api\n\nconst C%d = %d\n\n// {{C%d}} ${C%d} {{Missing}}\n", i, i, (i+1)%n, (i+n-1)%n)
+		path := filepath.Join(dir, fmt.Sprintf("f%03d.go", i))
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func TestJobsMatchSequential(t *testing.T) {
+	var outputs [2]map[string]string
+	for i, jobs := range []int{1, 8} {
+		dir := t.TempDir()
+		writePackage(t, dir, 50)
+		r := NewSwaggerVariableReplacer()
+		r.SetJobs(jobs)
+		capture(t, &os.Stderr, func() {
+			if err := r.ProcessDirectory(dir); err != nil {
+				t.Fatal(err)
+			}
+		})
+		outputs[i] = make(map[string]string)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, entry := range entries {
+			outputs[i][entry.Name()] = readTestFile(t, filepath.Join(dir, entry.Name()))
+		}
+		if len(r.Results()) != 50 {
+			t.Errorf("jobs %d: %d results, want 50", jobs, len(r.Results()))
+		}
+	}
+	if len(outputs[0]) != 50 {
+		t.Fatalf("%d files, want 50", len(outputs[0]))
+	}
+	for name, want := range outputs[0] {
+		if got := outputs[1][name]; got != want {
+			t.Errorf("%s: parallel %q, sequential %q", name, got, want)
+		}
+	}
+	if got := outputs[0]["f001.go"]; !strings.Contains(got, "// 2 0 {{Missing}}\n") {
+		t.Errorf("not substituted: %q", got)
+	}
+}
+
+func TestJobsDryRunInWalkOrder(t *testing.T) {
+	dir := t.TempDir()
+	writePackage(t, dir, 50)
+	for _, summary := range []bool{false, true} {
+		var want string
+		for _, jobs := range []int{1, 8, 8, 8} {
+			r := NewSwaggerVariableReplacer()
+			r.SetJobs(jobs)
+			r.SetSummary(summary)
+			var out string
+			capture(t, &os.Stderr, func() {
+				out = capture(t, &os.Stdout, func() {
+					if _, err := r.DryRunDirectory(dir); err != nil {
+						t.Fatal(err)
+					}
+				})
+			})
+			if jobs == 1 {
+				want = out
+			} else if out != want {
+				t.Fatalf("summary %v: parallel dry run printed\n%s\nsequential\n%s", summary, out, want)
+			}
+		}
+		if !strings.Contains(want, "f001.go") || strings.Index(want, "f001.go") > strings.Index(want, "f049.go") {
+			t.Errorf("summary %v: files out of order:\n%s", summary, want)
+		}
+	}
+}
+
+func benchmarkProcessDirectory(b *testing.B, jobs int) {
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer null.Close()
+	stderr := os.Stderr
+	os.Stderr = null
+	defer func() { os.Stderr = stderr }()
+
+	dir := b.TempDir()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		writePackage(b, dir, 200)
+		b.StartTimer()
+		r := NewSwaggerVariableReplacer()
+		r.SetJobs(jobs)
+		if err := r.ProcessDirectory(dir); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessDirectory(b *testing.B)      { benchmarkProcessDirectory(b, 1) }
+func BenchmarkProcessDirectoryJobs8(b *testing.B) { benchmarkProcessDirectory(b, 8) }
+
+func TestConflictingConstants(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\nconst StatusOK = 200\nconst Same = 1\n\n// {{StatusOK}}\n")
+	writeTestFile(t, dir, "b.go", "package api\n\nconst StatusOK = 201\nconst Same = 1\n\n// {{StatusOK}}\n")
+
+	r := NewSwaggerVariableReplacer()
+	r.SetStrict(true)
+	r.SetDryRun(true)
+	var err error
+	capture(t, &os.Stdout, func() { err = r.ProcessDirectory(dir) })
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("err = %v, want ErrConflict", err)
+	}
+	for _, location := range []string{"a.go:3", "b.go:3"} {
+		if !strings.Contains(err.Error(), location) {
+			t.Errorf("%s missing from %q", location, err)
+		}
+	}
+	conflicts := r.Conflicts()
+	if len(conflicts) != 1 || conflicts[0].Name != "StatusOK" {
+		t.Fatalf("Conflicts = %+v, want one for StatusOK", conflicts)
+	}
+
+	// With the file's own constants winning, each file keeps its value
+	r = NewSwaggerVariableReplacer()
+	r.SetPreferLocal(true)
+	capture(t, &os.Stderr, func() { err = r.ProcessDirectory(dir) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"a.go": "// 200\n", "b.go": "// 201\n"} {
+		if got := readTestFile(t, filepath.Join(dir, name)); !strings.HasSuffix(got, want) {
+			t.Errorf("%s: got %q, want it to end with %q", name, got, want)
+		}
+	}
+}
+
+func TestBlankIdentifierNotConflicting(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\nconst (\n\t_ = iota\n\tA\n)\n\nvar _ = map[string]int{\"k\": 1}\n\n// {{A}}\n")
+	writeTestFile(t, dir, "b.go", "package api\n\nconst (\n\t_ = iota + 7\n\tB\n)\n\nvar _ = map[string]int{\"k\": 2}\n\n// {{B}} {{_}}\n")
+
+	r := NewSwaggerVariableReplacer()
+	r.SetStrict(true)
+	r.SetDryRun(true)
+	var err error
+	capture(t, &os.Stdout, func() { err = r.ProcessDirectory(dir) })
+	if !errors.Is(err, ErrUnresolved) || strings.Contains(err.Error(), "_ is") {
+		t.Fatalf("err = %v, want only {{_}} unresolved", err)
+	}
+	if conflicts := r.Conflicts(); len(conflicts) != 0 {
+		t.Errorf("Conflicts = %+v", conflicts)
+	}
+	for _, c := range r.Constants() {
+		if strings.HasPrefix(c.Name, "_") {
+			t.Errorf("blank identifier recorded as %s", c.Name)
+		}
+	}
+}
+
+func TestFileScope(t *testing.T) {
+	for _, fileScope := range []bool{true, false} {
+		dir := t.TempDir()
+		writeTestFile(t, dir, "a.go", "package api\n\nconst Sibling = 1\n")
+		b := writeTestFile(t, dir, "b.go", "package api\n\nconst Own = 2\n\n// {{Own}} {{Sibling}}\n")
+
+		r := NewSwaggerVariableReplacer()
+		r.SetFileScope(fileScope)
+		capture(t, &os.Stderr, func() {
+			if err := r.ProcessDirectory(dir); err != nil {
+				t.Fatal(err)
+			}
+		})
+		want := "// 2 1\n"
+		if fileScope {
+			want = "// 2 {{Sibling}}\n"
+		}
+		if got := readTestFile(t, b); !strings.HasSuffix(got, want) {
+			t.Errorf("file scope %v: got %q, want it to end with %q", fileScope, got, want)
+		}
+	}
+}
+
+func TestMaxUnresolved(t *testing.T) {
+	for _, tt := range []struct {
+		max  int
+		fail bool
+	}{
+		{-1, false},
+		{1, true},
+		{2, false},
+	} {
+		dir := t.TempDir()
+		writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n\n// {{A}} {{One}}\n")
+		writeTestFile(t, dir, "b.go", "package api\n\n// ${Two} {{A}}\n")
+
+		r := NewSwaggerVariableReplacer()
+		r.SetMaxUnresolved(tt.max)
+		var err error
+		stderr := capture(t, &os.Stderr, func() { err = r.ProcessDirectory(dir) })
+		if failed := errors.Is(err, ErrUnresolved); failed != tt.fail {
+			t.Errorf("max %d: err = %v", tt.max, err)
+		}
+		if !strings.Contains(stderr, "Processed 2 file(s), 2 substitution(s), 2 unresolved\n") {
+			t.Errorf("max %d: no summary in %q", tt.max, stderr)
+		}
+	}
+}
+
+func TestConstants(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "b.go", "package api\n\nconst StatusOK = 200\nconst Rate = 0.5\n")
+	writeTestFile(t, dir, "a.go", "package api\n\nconst Name = \"svc\"\nconst Enabled = true\nconst Sep = '/'\n")
+	r := NewSwaggerVariableReplacer()
+	if err := r.ExtractFromDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	want := []Constant{
+		{Name: "Enabled", Value: true, Type: "bool", Location: filepath.Join(dir, "a.go") + ":4"},
+		{Name: "Name", Value: "svc", Type: "string", Location: filepath.Join(dir, "a.go") + ":3"},
+		{Name: "Rate", Value: 0.5, Type: "float64", Location: filepath.Join(dir, "b.go") + ":4"},
+		{Name: "Sep", Value: '/', Type: "rune", Location: filepath.Join(dir, "a.go") + ":5"},
+		{Name: "StatusOK", Value: 200, Type: "int", Location: filepath.Join(dir, "b.go") + ":3"},
+	}
+	got := r.Constants()
+	if len(got) != len(want) {
+		t.Fatalf("Constants = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Constants[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestProcessSource(t *testing.T) {
+	r := NewSwaggerVariableReplacer()
+	if err := r.ExtractConstantsFromSource([]byte("package consts\n\nconst StatusOK = 200\n")); err != nil {
+		t.Fatal(err)
+	}
+	out, err := r.ProcessSource(map[string]interface{}{"Name": "svc"}, []byte("package api\n\nconst Own = true\n\n// {{StatusOK}} {{Name}} {{Own}}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(out), "package api\n\nconst Own = true\n\n// 200 svc true\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if err := r.ExtractConstantsFromSource([]byte("not go")); err == nil {
+		t.Error("invalid source extracted")
+	}
+	if _, err := r.ProcessSource(nil, []byte("not go")); err == nil {
+		t.Error("invalid source processed")
+	}
+}
+
+func TestUnparseableFileSkipped(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		dir := t.TempDir()
+		writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n\n// {{A}}\n")
+		broken := writeTestFile(t, dir, "broken.go", "package api\n\nfunc {\n// {{A}}\n")
+		c := writeTestFile(t, dir, "c.go", "package api\n\n// {{A}}\n")
+
+		r := NewSwaggerVariableReplacer()
+		r.SetStrict(strict)
+		var err error
+		stderr := capture(t, &os.Stderr, func() { err = r.ProcessDirectory(dir) })
+		if err == nil {
+			t.Errorf("strict %v: no error for the unparseable file", strict)
+		}
+		if err != nil && !strings.Contains(stderr+err.Error(), "broken.go") {
+			t.Errorf("strict %v: parse error not reported: %v, %q", strict, err, stderr)
+		}
+		if got := readTestFile(t, broken); got != "package api\n\nfunc {\n// {{A}}\n" {
+			t.Errorf("strict %v: unparseable file modified: %q", strict, got)
+		}
+		if !strict {
+			if got := readTestFile(t, c); !strings.HasSuffix(got, "// 1\n") {
+				t.Errorf("good file not processed: %q", got)
+			}
+		}
+	}
+}
+
+func TestProcessDirectoryContextCanceled(t *testing.T) {
+	dir := t.TempDir()
+	writePackage(t, dir, 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewSwaggerVariableReplacer().ProcessDirectoryContext(ctx, dir); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if got := readTestFile(t, filepath.Join(dir, "f000.go")); strings.Contains(got, "// 1 ") {
+		t.Errorf("written after cancellation: %q", got)
+	}
+
+	// Canceled mid-walk, every file is either fully substituted or untouched
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	r := NewSwaggerVariableReplacer()
+	r.SetValidator(func(sub Substitution) error {
+		cancel()
+		return nil
+	})
+	var err error
+	capture(t, &os.Stderr, func() { err = r.ProcessDirectoryContext(ctx, dir) })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 20 {
+		t.Errorf("%d entries, want the 20 files without temporary ones", len(entries))
+	}
+	for _, entry := range entries {
+		got := readTestFile(t, filepath.Join(dir, entry.Name()))
+		if strings.Count(got, "{{C") != strings.Count(got, "${C") {
+			t.Errorf("%s partially substituted: %q", entry.Name(), got)
+		}
+	}
+}
+
+func TestUnresolvedWarningsSummarized(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\n// {{Missing}}\n// {{Missing}} {{Missing}}\n")
+	writeTestFile(t, dir, "b.go", "package api\n\n// ${Missing} {{Other}}\n")
+
+	r := NewSwaggerVariableReplacer()
+	stderr := capture(t, &os.Stderr, func() {
+		if err := r.ProcessDirectory(dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if n := strings.Count(stderr, "Warning:"); n != 2 {
+		t.Errorf("%d warnings, want one per variable: %q", n, stderr)
+	}
+	want := "Warning: Variable 'Missing' not found (4 occurrence(s), first at " + filepath.Join(dir, "a.go") + ":3)\n"
+	if !strings.Contains(stderr, want) {
+		t.Errorf("missing %q in %q", want, stderr)
+	}
+
+	r = NewSwaggerVariableReplacer()
+	r.SetVerbose(true)
+	stderr = capture(t, &os.Stderr, func() {
+		if err := r.ProcessDirectory(dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if n := strings.Count(stderr, "'Missing'"); n != 4 {
+		t.Errorf("verbose: %d warnings for Missing, want 4: %q", n, stderr)
+	}
+}
@@ -0,0 +1,115 @@
+package replacer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Report summarizes every file processed by a replacer
+type Report struct {
+	DryRun       bool      `json:"dry_run"`
+	FilesScanned int       `json:"files_scanned"`
+	Resolved     int       `json:"resolved"`
+	Unresolved   int       `json:"unresolved"`
+	Files        []*Result `json:"files"` // files changed or with unresolved variables
+	// Usage counts the placeholders resolved for each variable
+	Usage []VariableUsage `json:"usage"`
+}
+
+// VariableUsage is the number of placeholders a variable resolved
+type VariableUsage struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Report builds a summary of every file processed so far
+func (r *SwaggerVariableReplacer) Report() *Report {
+	report := &Report{
+		DryRun:       r.dryRun,
+		FilesScanned: len(r.results),
+		Files:        []*Result{},
+		Usage:        r.Usage(),
+	}
+	for _, res := range r.results {
+		report.Resolved += len(res.Substitutions)
+		report.Unresolved += len(res.missing)
+		if res.LinesChanged > 0 || len(res.missing) > 0 {
+			if r.root != "" {
+				relative := *res
+				relative.File = r.displayPath(res.File)
+				res = &relative
+			}
+			report.Files = append(report.Files, res)
+		}
+	}
+	return report
+}
+
+// SetRoot makes reports, diffs and backup directories name files by their
+// slash-separated path relative to dir, so that they don't depend on the
+// working directory. Files outside dir keep their path as given.
+func (r *SwaggerVariableReplacer) SetRoot(dir string) {
+	r.root = dir
+}
+
+// displayPath returns path relative to the root set with SetRoot, or path
+// itself without a root or if it lies outside the root
+func (r *SwaggerVariableReplacer) displayPath(path string) string {
+	if r.root == "" {
+		return path
+	}
+	root, err := filepath.Abs(r.root)
+	if err != nil {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Usage returns how many placeholders each variable resolved in the files
+// processed so far, most used first, including every known constant never
+// referenced with a count of zero
+func (r *SwaggerVariableReplacer) Usage() []VariableUsage {
+	counts := make(map[string]int)
+	for _, c := range r.Constants() {
+		counts[c.Name] = 0
+	}
+	for _, res := range r.results {
+		for _, sub := range res.Substitutions {
+			if sub.Pattern != "reverse" {
+				counts[sub.Name]++
+			}
+		}
+	}
+
+	usage := make([]VariableUsage, 0, len(counts))
+	for name, count := range counts {
+		usage = append(usage, VariableUsage{Name: name, Count: count})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Count != usage[j].Count {
+			return usage[i].Count > usage[j].Count
+		}
+		return usage[i].Name < usage[j].Name
+	})
+	return usage
+}
+
+// WriteReport writes the report of every file processed so far to path as JSON
+func (r *SwaggerVariableReplacer) WriteReport(path string) error {
+	content, err := json.MarshalIndent(r.Report(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
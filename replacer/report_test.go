@@ -0,0 +1,142 @@
+package replacer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	for _, dryRun := range []bool{false, true} {
+		dir := t.TempDir()
+		writeTestFile(t, dir, "a.go", "package api\n\nconst StatusOK = 200\n\n// @Success {{StatusOK}}\n// ${StatusOK} {{Missing}}\n")
+		writeTestFile(t, dir, "b.go", "package api\n\n// nothing to do\n")
+
+		r := NewSwaggerVariableReplacer()
+		r.SetDryRun(dryRun)
+		r.SetRoot(dir)
+		capture(t, &os.Stderr, func() {
+			capture(t, &os.Stdout, func() {
+				if err := r.ProcessDirectory(dir); err != nil {
+					t.Fatal(err)
+				}
+			})
+		})
+		path := filepath.Join(t.TempDir(), "report.json")
+		if err := r.WriteReport(path); err != nil {
+			t.Fatal(err)
+		}
+
+		var report struct {
+			DryRun       bool `json:"dry_run"`
+			FilesScanned int  `json:"files_scanned"`
+			Resolved     int  `json:"resolved"`
+			Unresolved   int  `json:"unresolved"`
+			Files        []struct {
+				File          string `json:"file"`
+				LinesChanged  int    `json:"lines_changed"`
+				Substitutions []struct {
+					Pattern string      `json:"pattern"`
+					Name    string      `json:"name"`
+					Value   interface{} `json:"value"`
+					Line    int         `json:"line"`
+				} `json:"substitutions"`
+				Unresolved []string `json:"unresolved"`
+			} `json:"files"`
+		}
+		if err := json.Unmarshal([]byte(readTestFile(t, path)), &report); err != nil {
+			t.Fatal(err)
+		}
+		if report.DryRun != dryRun || report.FilesScanned != 2 || report.Resolved != 2 || report.Unresolved != 1 {
+			t.Errorf("dry run %v: report %+v", dryRun, report)
+		}
+		if len(report.Files) != 1 {
+			t.Fatalf("dry run %v: files %+v, want only a.go", dryRun, report.Files)
+		}
+		file := report.Files[0]
+		if file.File != "a.go" || file.LinesChanged != 2 || len(file.Unresolved) != 1 || file.Unresolved[0] != "Missing" {
+			t.Errorf("dry run %v: file %+v", dryRun, file)
+		}
+		if len(file.Substitutions) != 2 {
+			t.Fatalf("dry run %v: substitutions %+v", dryRun, file.Substitutions)
+		}
+		sub := file.Substitutions[1]
+		if sub.Pattern != "dollar" || sub.Name != "StatusOK" || sub.Value != 200.0 || sub.Line != 6 {
+			t.Errorf("dry run %v: substitution %+v", dryRun, sub)
+		}
+	}
+}
+
+func TestUsage(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "consts.go", "package api\n\nconst A = 1\nconst B = 2\nconst Dead = 3\n")
+	writeTestFile(t, dir, "a.go", "package api\n\n// {{A}} ${A} {{B}}\n// @VAR(A) {{Missing}}\n")
+	r := NewSwaggerVariableReplacer()
+	capture(t, &os.Stderr, func() {
+		if err := r.ProcessDirectory(dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+	want := []VariableUsage{{"A", 3}, {"B", 1}, {"Dead", 0}}
+	got := r.Usage()
+	if len(got) != len(want) {
+		t.Fatalf("Usage = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Usage[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if report := r.Report(); len(report.Usage) != len(want) {
+		t.Errorf("report usage = %v, want %v", report.Usage, want)
+	}
+}
+
+func TestRootRelativePaths(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "api/v1/a.go", "package api\n\nconst A = 1\n\n// {{A}}\n")
+	outside := writeTestFile(t, t.TempDir(), "b.go", "package b\n\nconst B = 2\n\n// {{B}}\n")
+
+	r := NewSwaggerVariableReplacer()
+	r.SetRoot(dir)
+	r.SetDryRun(true)
+	out := capture(t, &os.Stdout, func() {
+		if err := r.ProcessPaths([]string{path, outside}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, "--- api/v1/a.go\n+++ api/v1/a.go\n") {
+		t.Errorf("diff not relative to the root:\n%s", out)
+	}
+	if !strings.Contains(out, "--- "+outside+"\n") {
+		t.Errorf("path outside the root not kept as given:\n%s", out)
+	}
+	files := r.Report().Files
+	if len(files) != 2 || files[0].File != "api/v1/a.go" || files[1].File != outside {
+		t.Errorf("report files %+v", files)
+	}
+}
+
+func TestGCCWarningFormat(t *testing.T) {
+	path := writeTestFile(t, t.TempDir(), "a.go", "package api\n\nconst A = 1\n\n// {{A}}  {{Missing}}\n")
+	r := NewSwaggerVariableReplacer()
+	if err := r.SetWarningFormat("gcc"); err != nil {
+		t.Fatal(err)
+	}
+	stderr := capture(t, &os.Stderr, func() {
+		if _, err := r.ProcessFile(path); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if want := path + ":5:11: unresolved variable 'Missing'\n"; !strings.Contains(stderr, want) {
+		t.Errorf("stderr = %q, want it to contain %q", stderr, want)
+	}
+	if strings.Contains(stderr, "Warning:") {
+		t.Errorf("prose warning in %q", stderr)
+	}
+	if err := r.SetWarningFormat("json"); err == nil {
+		t.Error("no error for an unknown format")
+	}
+}
@@ -0,0 +1,122 @@
+package replacer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LoadMapping reads a JSON object mapping substituted values back to the
+// placeholders they came from, as in {"200": "{{StatusOK}}"}
+func LoadMapping(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mapping: %v", err)
+	}
+
+	var mapping map[string]string
+	if err := json.Unmarshal(content, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping %s: %v", path, err)
+	}
+	return mapping, nil
+}
+
+// SetReverse switches the replacer to reverse mode: instead of substituting
+// placeholders, every occurrence of a value of mapping in a comment is
+// replaced by its placeholder, so substituted files can be templatized
+// again. Where values overlap, the longest one wins. A nil mapping turns
+// reverse mode off.
+func (r *SwaggerVariableReplacer) SetReverse(mapping map[string]string) {
+	r.reverse = nil
+	for value, placeholder := range mapping {
+		if value != "" {
+			r.reverse = append(r.reverse, reversal{value: value, placeholder: placeholder})
+		}
+	}
+	sort.Slice(r.reverse, func(i, j int) bool {
+		a, b := r.reverse[i], r.reverse[j]
+		if len(a.value) != len(b.value) {
+			return len(a.value) > len(b.value)
+		}
+		return a.value < b.value
+	})
+}
+
+// reversal is a value to turn back into its placeholder in reverse mode
+type reversal struct {
+	value, placeholder string
+}
+
+// reverseCommentLine replaces the known values in a comment with their
+// placeholders, scanning left to right and trying longer values first.
+// Values only match as whole words, so 200 isn't found in 2000. The comment
+// markers themselves are never touched.
+func (r *SwaggerVariableReplacer) reverseCommentLine(comment string, lineNo int, res *Result) string {
+	start, end := 0, len(comment)
+	if strings.HasPrefix(comment, "//") || strings.HasPrefix(comment, "/*") {
+		start = 2
+	}
+	// Block comments, and lines continuing one, may end with a closing marker
+	if !strings.HasPrefix(comment, "//") && strings.HasSuffix(comment[start:], "*/") {
+		end -= 2
+	}
+
+	var b strings.Builder
+	b.WriteString(comment[:start])
+	for i := start; i < end; {
+		matched := false
+		for _, rev := range r.reverse {
+			if i+len(rev.value) <= end && strings.HasPrefix(comment[i:], rev.value) && atBoundaries(comment, i, i+len(rev.value)) {
+				b.WriteString(rev.placeholder)
+				res.Substitutions = append(res.Substitutions, Substitution{
+					Pattern: "reverse",
+					Name:    rev.placeholder,
+					Value:   rev.value,
+					Old:     rev.value,
+					New:     rev.placeholder,
+					File:    res.File,
+					Line:    lineNo,
+				})
+				i += len(rev.value)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteByte(comment[i])
+			i++
+		}
+	}
+	b.WriteString(comment[end:])
+	return b.String()
+}
+
+// atBoundaries reports whether text[start:end] isn't part of a longer word
+// or number: where it starts or ends with a letter, digit or underscore, the
+// character beyond it must not be one
+func atBoundaries(text string, start, end int) bool {
+	if start > 0 {
+		first, _ := utf8.DecodeRuneInString(text[start:])
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		if isWordChar(first) && isWordChar(before) {
+			return false
+		}
+	}
+	if end < len(text) {
+		last, _ := utf8.DecodeLastRuneInString(text[:end])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if isWordChar(last) && isWordChar(after) {
+			return false
+		}
+	}
+	return true
+}
+
+// isWordChar reports whether c can be part of an identifier or number
+func isWordChar(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
@@ -0,0 +1,41 @@
+package replacer
+
+import (
+	"testing"
+)
+
+func TestReverseRoundTrip(t *testing.T) {
+	templated := `package api
+
+const StatusOK = 200
+const Status = 20
+const Path = "/api/v1"
+
+// @Success {{StatusOK}} at {{Path}}
+// @Failure {{Status}} /* {{Path}} */
+var x = "200 /api/v1" // {{StatusOK}}
+`
+	substituted := process(t, NewSwaggerVariableReplacer(), templated)
+
+	r := NewSwaggerVariableReplacer()
+	r.SetReverse(map[string]string{
+		"200":     "{{StatusOK}}",
+		"20":      "{{Status}}",
+		"/api/v1": "{{Path}}",
+	})
+	got := process(t, r, substituted)
+	// Values in code and in the declarations themselves stay as they are
+	if got != templated {
+		t.Errorf("round trip:\ngot:\n%s\nwant:\n%s", got, templated)
+	}
+}
+
+func TestReverseWholeWords(t *testing.T) {
+	r := NewSwaggerVariableReplacer()
+	r.SetReverse(map[string]string{"200": "{{A}}", "v1": "{{V}}", "/api": "{{P}}"})
+	src := "package api\n\n// 2000 too, 1200, 200x, x200, _200\n// 200, (200) v1/api v10 /api/v1 /apis\n"
+	want := "package api\n\n// 2000 too, 1200, 200x, x200, _200\n// {{A}}, ({{A}}) {{V}}{{P}} v10 {{P}}/{{V}} /apis\n"
+	if got := process(t, r, src); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
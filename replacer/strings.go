@@ -0,0 +1,56 @@
+package replacer
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SetInStrings makes substitution also replace placeholders inside string
+// literals, such as the {{APIVersion}} of "/api/{{APIVersion}}/users",
+// which changes code rather than documentation. Values are escaped for
+// interpreted strings; a value containing a backquote is left out of raw
+// strings.
+func (r *SwaggerVariableReplacer) SetInStrings(enabled bool) {
+	r.inStrings = enabled
+}
+
+// addStringSpans adds the contents of the string literals of content,
+// quotes excluded, to the comment spans of each line, keeping them in
+// order; lines holds content split on "\n" with any "\r" endings removed
+func addStringSpans(spans [][]commentSpan, filename string, content []byte, lines []string) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, content, 0)
+	if err != nil {
+		return err
+	}
+
+	starts := lineStarts(content)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING && len(lit.Value) >= 2 {
+			start := fset.PositionFor(lit.Pos()+1, false)
+			end := fset.PositionFor(lit.End()-1, false)
+			addSpan(spans, lines, starts, start, end, lit.Value[0])
+		}
+		return true
+	})
+	for _, line := range spans {
+		sort.Slice(line, func(i, j int) bool {
+			return line[i].start < line[j].start
+		})
+	}
+	return nil
+}
+
+// quoteValue renders a substituted value for a string literal with the
+// given quote, reporting false if it can't be written in a raw string
+func quoteValue(value string, quote byte) (string, bool) {
+	if quote == '`' {
+		return value, !strings.Contains(value, "`")
+	}
+	quoted := strconv.Quote(value)
+	return quoted[1 : len(quoted)-1], true
+}
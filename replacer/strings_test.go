@@ -0,0 +1,31 @@
+package replacer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInStrings(t *testing.T) {
+	src := "package api\n\nconst APIVersion = \"v1\"\nconst Quote = `say \"hi\"`\nconst Tick = \"a`b\"\n\n" +
+		"var url = \"/api/{{APIVersion}}/x\" // {{APIVersion}}\n" +
+		"var msg = \"{{Quote}}\"\n" +
+		"var raw = `{{APIVersion}} {{Tick}}`\n"
+
+	got := process(t, NewSwaggerVariableReplacer(), src)
+	if !strings.Contains(got, "var url = \"/api/{{APIVersion}}/x\" // v1\n") || !strings.Contains(got, "var msg = \"{{Quote}}\"\n") {
+		t.Errorf("strings substituted without SetInStrings:\n%s", got)
+	}
+
+	r := NewSwaggerVariableReplacer()
+	r.SetInStrings(true)
+	got = process(t, r, src)
+	for _, want := range []string{
+		"var url = \"/api/v1/x\" // v1\n",
+		"var msg = \"say \\\"hi\\\"\"\n",
+		"var raw = `v1 {{Tick}}`\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package api
+
+// HTTP status codes, each documented on its own line
+const (
+	StatusSuccess      = 200 // OK
+	StatusCreated      = 201 // Created
+	StatusBadRequest   = 400 /* Bad Request */
+	StatusUnauthorized = 401 // Unauthorized, see {{StatusBadRequest}}
+	StatusNotFound     = 404 // Not Found
+	StatusServerError  = 500 // Internal Server Error
+)
+
+// Response messages
+const (
+	MessageSuccess  = "Operation completed successfully" // returned with {{StatusSuccess}}
+	MessageNotFound = "Resource not found"               // returned with {{StatusNotFound}}
+)
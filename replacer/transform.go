@@ -0,0 +1,60 @@
+package replacer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Transform renders a resolved value for a {{Name:format}} placeholder,
+// given the value and its default rendering. It reports false if the value
+// doesn't suit the format, in which case the default rendering is kept.
+type Transform func(value interface{}, text string) (string, bool)
+
+// builtinTransforms are the formats every replacer knows
+var builtinTransforms = map[string]Transform{
+	"upper": func(_ interface{}, text string) (string, bool) {
+		return strings.ToUpper(text), true
+	},
+	"lower": func(_ interface{}, text string) (string, bool) {
+		return strings.ToLower(text), true
+	},
+	"hex": func(value interface{}, _ string) (string, bool) {
+		switch v := value.(type) {
+		case int, rune:
+			return fmt.Sprintf("%#x", v), true
+		case string:
+			return fmt.Sprintf("%x", v), true
+		}
+		return "", false
+	},
+}
+
+// AddTransform registers fn as the format name of {{Name:format}}
+// placeholders, replacing any previous format of that name
+func (r *SwaggerVariableReplacer) AddTransform(name string, fn Transform) {
+	if r.transforms == nil {
+		r.transforms = make(map[string]Transform)
+	}
+	r.transforms[name] = fn
+}
+
+// transform applies the format of a placeholder to the rendering of its
+// value, warning and keeping text if the format is unknown or doesn't suit
+// the value
+func (r *SwaggerVariableReplacer) transform(p placeholder, value interface{}, text string) string {
+	fn, exists := r.transforms[p.format]
+	if !exists {
+		fn, exists = builtinTransforms[p.format]
+	}
+	if !exists {
+		fmt.Fprintf(os.Stderr, "Warning: Unknown format '%s' for variable '%s'\n", p.format, p.name)
+		return text
+	}
+	transformed, ok := fn(value, text)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Warning: Format '%s' doesn't apply to the %s value of variable '%s'\n", p.format, typeName(value), p.name)
+		return text
+	}
+	return transformed
+}
@@ -0,0 +1,39 @@
+package replacer
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTransforms(t *testing.T) {
+	src := "package api\n\nconst Name = \"Svc\"\nconst Code = 255\nconst Rate = 0.5\n\n// %s\n"
+	tests := []struct {
+		comment, want, warning string
+	}{
+		{"{{Name:upper}} {{Name:lower}} {{ Name : upper }}", "SVC svc SVC", ""},
+		{"{{Code:hex}} {{Name:hex}}", "0xff 537663", ""},
+		{"{{Code:upper}}", "255", ""},
+		{"{{Rate:hex}}", "0.5", "doesn't apply"},
+		{"{{Name:title}}", "Svc", "title"},
+		{"{{Name:reverse}}", "cvS", ""},
+	}
+	for _, tt := range tests {
+		r := NewSwaggerVariableReplacer()
+		r.AddTransform("reverse", func(_ interface{}, text string) (string, bool) {
+			runes := []rune(text)
+			for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+				runes[i], runes[j] = runes[j], runes[i]
+			}
+			return string(runes), true
+		})
+		var got string
+		stderr := capture(t, &os.Stderr, func() { got = process(t, r, strings.Replace(src, "%s", tt.comment, 1)) })
+		if !strings.HasSuffix(got, "// "+tt.want+"\n") {
+			t.Errorf("%s: got %q, want %q", tt.comment, got, tt.want)
+		}
+		if warned := stderr != ""; warned != (tt.warning != "") || !strings.Contains(stderr, tt.warning) {
+			t.Errorf("%s: warnings %q", tt.comment, stderr)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package replacer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// shouldProcess reports whether a walked file is a Go file to process;
+// rel is its slash-separated path relative to the walk root, and test files
+// are only processed if tests is set
+func (r *SwaggerVariableReplacer) shouldProcess(rel string, tests bool) bool {
+	if !strings.HasSuffix(rel, ".go") || (strings.HasSuffix(rel, "_test.go") && !tests) {
+		return false
+	}
+	return !r.isExcluded(rel, false)
+}
+
+// matchesBuild reports whether the file at path satisfies the build
+// constraints set with SetBuildTags, including GOOS and GOARCH file name
+// suffixes. Every file matches when no build tags were set, and files whose
+// constraints can't be read are left for parsing to report.
+func (r *SwaggerVariableReplacer) matchesBuild(path string) bool {
+	if r.buildContext == nil {
+		return true
+	}
+	dir, name := filepath.Split(path)
+	match, err := r.buildContext.MatchFile(dir, name)
+	return match || err != nil
+}
+
+// generatedPattern is the header marking generated Go files, see
+// https://go.dev/s/generatedcode
+var generatedPattern = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// isGenerated reports whether the file at path has a generated code header
+// before its package clause. Generated files are never considered when
+// SetIncludeGenerated is on, or if the file can't be read.
+func (r *SwaggerVariableReplacer) isGenerated(path string) bool {
+	if r.includeGenerated {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if generatedPattern.MatchString(line) {
+			return true
+		}
+		if strings.HasPrefix(line, "package ") {
+			return false
+		}
+	}
+	return false
+}
+
+// skipDir reports whether a walk should skip the directory at rel, the
+// slash-separated path relative to the walk root, because it is excluded
+// or deeper than the maximum depth
+func (r *SwaggerVariableReplacer) skipDir(rel string) bool {
+	if rel == "." {
+		return false
+	}
+	if r.maxDepth >= 0 && strings.Count(rel, "/")+1 > r.maxDepth {
+		return true
+	}
+	return r.isExcluded(rel, true)
+}
+
+// isExcluded reports whether rel, a directory if isDir, matches any exclude
+// pattern and isn't re-included by the ignore file. Patterns without a
+// slash also match the base name, as in .gitignore.
+func (r *SwaggerVariableReplacer) isExcluded(rel string, isDir bool) bool {
+	excluded := false
+	for _, glob := range r.excludes {
+		if matchExclude(filepath.ToSlash(glob), rel) {
+			excluded = true
+			break
+		}
+	}
+	// As in .gitignore, the last rule matching decides
+	for _, rule := range r.ignoreRules {
+		if (isDir || !rule.dirOnly) && rule.matches(rel) {
+			excluded = !rule.negate
+		}
+	}
+	return excluded
+}
+
+// matchExclude matches rel against an exclude pattern, which also matches
+// the base name if it has no slash
+func matchExclude(glob, rel string) bool {
+	if matchGlob(glob, rel) {
+		return true
+	}
+	return !strings.Contains(glob, "/") && matchGlob(glob, path.Base(rel))
+}
+
+// matchGlob matches a slash-separated path against a filepath.Match
+// pattern in which a `**` segment matches zero or more path segments
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if matched, _ := filepath.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// walkGoFiles calls fn for every Go file under dir that should be processed,
+// including test files if tests is set, skipping generated files, and
+// excluded and too deep directories entirely.
+// The ignore file at the root of dir, if any, applies for the walk, which
+// stops with ctx's error once ctx is done.
+//
+// Symlinked directories are skipped unless SetFollowSymlinks is on, and
+// every file is processed once whatever the number of links to it, through
+// the first path found to it.
+func (r *SwaggerVariableReplacer) walkGoFiles(ctx context.Context, dir string, tests bool, fn func(path string) error) error {
+	rules, err := readIgnoreFile(dir)
+	if err != nil {
+		return err
+	}
+	r.ignoreRules = rules
+
+	// Real paths of the directories and files walked so far, which also
+	// stops followed symlinks from looping
+	seen := make(map[string]bool)
+	firstVisit := func(path string) bool {
+		real, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return true
+		}
+		if seen[real] {
+			return false
+		}
+		seen[real] = true
+		return true
+	}
+
+	// walk walks root, the real directory a followed symlink at linked
+	// points to, or dir itself
+	var walk func(root, linked string) error
+	walk = func(root, linked string) error {
+		return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if linked != root {
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				path = filepath.Join(linked, rel)
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+
+			if info.Mode()&os.ModeSymlink != 0 {
+				target, err := os.Stat(path)
+				if err != nil {
+					return nil // dangling links are no Go files
+				}
+				if target.IsDir() {
+					// dir itself is always followed when it is a link
+					if (!r.followSymlinks && rel != ".") || r.skipDir(rel) {
+						return nil
+					}
+					real, err := filepath.EvalSymlinks(path)
+					if err != nil {
+						return err
+					}
+					return walk(real, path)
+				}
+				info = target
+			}
+			if info.IsDir() {
+				if r.skipDir(rel) || !firstVisit(path) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if r.shouldProcess(rel, tests) && r.matchesBuild(path) && !r.isGenerated(path) && firstVisit(path) {
+				return fn(path)
+			}
+			return nil
+		})
+	}
+	return walk(dir, dir)
+}
+
+// forEachGoFile calls fn for every Go file under dir like walkGoFiles, using
+// up to r.jobs concurrent workers and counting progress through phase.
+// Results and unresolved variables recorded by fn are put back in walk
+// order, dry-run output is printed in that order once all files are done,
+// and the error of the first failing file in walk order is returned.
+// Once ctx is done, no further file is started and ctx's error is returned.
+func (r *SwaggerVariableReplacer) forEachGoFile(ctx context.Context, dir, phase string, tests bool, fn func(path string) error) error {
+	if r.jobs <= 1 && r.progress == nil {
+		return r.walkGoFiles(ctx, dir, tests, fn)
+	}
+
+	// Files are listed upfront to know the total the progress counts to
+	var paths []string
+	err := r.walkGoFiles(ctx, dir, tests, func(path string) error {
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	progress := r.newProgress(phase, len(paths))
+	defer progress.finish()
+
+	if r.jobs <= 1 {
+		for _, path := range paths {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(path); err != nil {
+				return err
+			}
+			progress.step()
+		}
+		return nil
+	}
+
+	resultsFrom, unresolvedFrom, malformedFrom := len(r.results), len(r.unresolved), len(r.malformed)
+	errs := make([]error, len(paths))
+	r.holdPreviews = true
+	defer func() { r.holdPreviews = false }()
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < r.jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if errs[i] = ctx.Err(); errs[i] == nil {
+					errs[i] = fn(paths[i])
+				}
+				progress.step()
+			}
+		}()
+	}
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	order := make(map[string]int, len(paths))
+	for i, path := range paths {
+		order[path] = i
+	}
+	results := r.results[resultsFrom:]
+	sort.SliceStable(results, func(i, j int) bool {
+		return order[results[i].File] < order[results[j].File]
+	})
+	unresolved := r.unresolved[unresolvedFrom:]
+	sort.SliceStable(unresolved, func(i, j int) bool {
+		return order[unresolved[i].file] < order[unresolved[j].file]
+	})
+	malformed := r.malformed[malformedFrom:]
+	sort.SliceStable(malformed, func(i, j int) bool {
+		return order[malformed[i].file] < order[malformed[j].file]
+	})
+	for _, res := range results {
+		fmt.Print(res.preview)
+		res.preview = ""
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package replacer
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"vendor/**", "vendor/a.go", true},
+		{"vendor/**", "vendor/x/y/a.go", true},
+		{"vendor/**", "src/vendor/a.go", false},
+		{"**/mocks/*.go", "mocks/a.go", true},
+		{"**/mocks/*.go", "a/b/mocks/a.go", true},
+		{"**/mocks/*.go", "a/b/mocks/x/a.go", false},
+		{"*.go", "a.go", true},
+		{"*.go", "x/a.go", false},
+		{"a/**/b.go", "a/b.go", true},
+		{"a/**/b.go", "a/x/y/b.go", true},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExcludedFilesUntouched(t *testing.T) {
+	dir := t.TempDir()
+	mock := "package mocks\n\nconst Hidden = 1\n\n// {{Visible}}\n"
+	vendored := "package dep\n\nconst Vendored = 2\n\n// {{Visible}}\n"
+	writeTestFile(t, dir, "mocks/m.go", mock)
+	writeTestFile(t, dir, "vendor/dep/d.go", vendored)
+	writeTestFile(t, dir, "a.go", "package api\n\nconst Visible = 3\n\n// {{Visible}} {{Hidden}} {{Vendored}}\n")
+
+	r := NewSwaggerVariableReplacer()
+	if err := r.ApplyConfig(&Config{ExcludeFiles: []string{"**/mocks/*.go", "vendor/**"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ProcessDirectory(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, dir+"/mocks/m.go"); got != mock {
+		t.Errorf("excluded mock modified: %q", got)
+	}
+	if got := readTestFile(t, dir+"/vendor/dep/d.go"); got != vendored {
+		t.Errorf("excluded vendored file modified: %q", got)
+	}
+	if got := readTestFile(t, dir+"/a.go"); !strings.Contains(got, "// 3 {{Hidden}} {{Vendored}}\n") {
+		t.Errorf("constants of excluded files used: %q", got)
+	}
+}
+
+func TestBuildConstraints(t *testing.T) {
+	dir := t.TempDir()
+	ignored := "//go:build ignore\n\npackage api\n\nconst A = 2\n\n// {{A}}\n"
+	writeTestFile(t, dir, "ignored.go", ignored)
+	tagged := "//go:build special\n\npackage api\n\nconst B = 3\n"
+	writeTestFile(t, dir, "tagged.go", tagged)
+	a := writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n\n// {{A}} {{B}}\n")
+
+	r := NewSwaggerVariableReplacer()
+	r.SetBuildTags(nil)
+	capture(t, &os.Stderr, func() {
+		if err := r.ProcessDirectory(dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if got := readTestFile(t, dir+"/ignored.go"); got != ignored {
+		t.Errorf("ignored file processed: %q", got)
+	}
+	if got := readTestFile(t, a); !strings.HasSuffix(got, "// 1 {{B}}\n") {
+		t.Errorf("constants of excluded files used: %q", got)
+	}
+
+	r = NewSwaggerVariableReplacer()
+	r.SetBuildTags([]string{"special"})
+	if err := r.ProcessDirectory(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, a); !strings.HasSuffix(got, "// 1 3\n") {
+		t.Errorf("constants of tagged file not used: %q", got)
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	for _, tt := range []struct {
+		depth   int
+		changed []string
+	}{
+		{0, []string{"a.go"}},
+		{1, []string{"a.go", "x/b.go"}},
+		{-1, []string{"a.go", "x/b.go", "x/y/c.go"}},
+	} {
+		dir := t.TempDir()
+		src := "package api\n\nconst A = 1\n\n// {{A}}\n"
+		for _, name := range []string{"a.go", "x/b.go", "x/y/c.go"} {
+			writeTestFile(t, dir, name, src)
+		}
+		r := NewSwaggerVariableReplacer()
+		r.SetMaxDepth(tt.depth)
+		capture(t, &os.Stderr, func() {
+			if err := r.ProcessDirectory(dir); err != nil {
+				t.Fatal(err)
+			}
+		})
+		var changed []string
+		for _, name := range []string{"a.go", "x/b.go", "x/y/c.go"} {
+			if readTestFile(t, dir+"/"+name) != src {
+				changed = append(changed, name)
+			}
+		}
+		if strings.Join(changed, " ") != strings.Join(tt.changed, " ") {
+			t.Errorf("depth %d: changed %v, want %v", tt.depth, changed, tt.changed)
+		}
+	}
+}
+
+func TestGeneratedFilesSkipped(t *testing.T) {
+	for _, include := range []bool{false, true} {
+		dir := t.TempDir()
+		generated := "// Code generated by mockgen. DO NOT EDIT.\n\npackage api\n\nconst G = 2\n\n// {{A}}\n"
+		gen := writeTestFile(t, dir, "gen.go", generated)
+		a := writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n\n// {{G}}\n")
+
+		r := NewSwaggerVariableReplacer()
+		r.SetIncludeGenerated(include)
+		capture(t, &os.Stderr, func() {
+			if err := r.ProcessDirectory(dir); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if got := readTestFile(t, gen); (got != generated) != include {
+			t.Errorf("include %v: generated file %q", include, got)
+		}
+		if got := readTestFile(t, a); strings.HasSuffix(got, "// 2\n") != include {
+			t.Errorf("include %v: constants of the generated file: %q", include, got)
+		}
+	}
+}
+
+func TestIncludeTests(t *testing.T) {
+	for _, include := range []bool{false, true} {
+		dir := t.TempDir()
+		a := writeTestFile(t, dir, "a.go", "package api\n\n// {{Example}}\n")
+		test := writeTestFile(t, dir, "a_test.go", "package api\n\nconst Example = \"ex\"\n\n// {{Example}}\n")
+		r := NewSwaggerVariableReplacer()
+		r.SetIncludeTests(include)
+		capture(t, &os.Stderr, func() {
+			if err := r.ProcessDirectory(dir); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if got := readTestFile(t, a); strings.HasSuffix(got, "// ex\n") != include {
+			t.Errorf("include %v: a.go %q", include, got)
+		}
+		if got := readTestFile(t, test); !strings.HasSuffix(got, "// {{Example}}\n") {
+			t.Errorf("include %v: a_test.go processed: %q", include, got)
+		}
+	}
+}
+
+func TestProcessTests(t *testing.T) {
+	for _, tt := range []struct {
+		include, process bool
+		want             string
+	}{
+		{false, false, "// {{A}} {{Example}}\n"},
+		{false, true, "// 1 {{Example}}\n"},
+		{true, false, "// {{A}} {{Example}}\n"},
+		{true, true, "// 1 ex\n"},
+	} {
+		dir := t.TempDir()
+		writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n")
+		test := writeTestFile(t, dir, "a_test.go", "package api\n\nconst Example = \"ex\"\n\n// {{A}} {{Example}}\n")
+		r := NewSwaggerVariableReplacer()
+		r.SetIncludeTests(tt.include)
+		r.SetProcessTests(tt.process)
+		capture(t, &os.Stderr, func() {
+			if err := r.ProcessDirectory(dir); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if got := readTestFile(t, test); !strings.HasSuffix(got, tt.want) {
+			t.Errorf("include %v, process %v: a_test.go %q, want suffix %q", tt.include, tt.process, got, tt.want)
+		}
+	}
+}
+
+func TestSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n\n// {{A}}\n")
+	writeTestFile(t, outside, "ext/e.go", "package ext\n\n// {{A}}\n")
+	if err := os.Symlink(dir+"/a.go", dir+"/link.go"); err != nil {
+		t.Skip(err)
+	}
+	if err := os.Symlink(outside+"/ext", dir+"/ext"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(dir, dir+"/loop"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, follow := range []bool{false, true} {
+		r := NewSwaggerVariableReplacer()
+		r.SetFollowSymlinks(follow)
+		capture(t, &os.Stderr, func() {
+			if err := r.ProcessDirectory(dir); err != nil {
+				t.Fatal(err)
+			}
+		})
+		processed := 0
+		for _, res := range r.Results() {
+			if strings.HasSuffix(res.File, "a.go") || strings.HasSuffix(res.File, "link.go") {
+				processed++
+			}
+		}
+		if processed != 1 {
+			t.Errorf("follow %v: a.go processed %d times", follow, processed)
+		}
+		if fi, err := os.Lstat(dir + "/link.go"); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+			t.Errorf("follow %v: link replaced by a file", follow)
+		}
+		got := readTestFile(t, outside+"/ext/e.go")
+		if strings.HasSuffix(got, "// 1\n") != follow {
+			t.Errorf("follow %v: symlinked directory %q", follow, got)
+		}
+	}
+}
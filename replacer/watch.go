@@ -0,0 +1,180 @@
+package replacer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce coalesces the bursts of events editors produce on save
+const watchDebounce = 200 * time.Millisecond
+
+// Watch processes dir like ProcessDirectory, then keeps watching it and
+// re-extracts and re-processes every Go file written afterwards until ctx
+// is done. Excluded paths and backups are ignored, and so are test files
+// unless SetProcessTests is on.
+func (r *SwaggerVariableReplacer) Watch(ctx context.Context, dir string) error {
+	// Files that failed were reported, and may be fixed while watching
+	if err := r.ProcessDirectory(dir); err != nil && len(r.failed) == 0 {
+		return err
+	}
+	r.stamps = make(map[string]fileStamp)
+	r.walkGoFiles(context.Background(), dir, r.processTests, func(path string) error {
+		r.stamp(path)
+		return nil
+	})
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := r.watchTree(watcher, dir, dir); err != nil {
+		return err
+	}
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("watch failed: %v", err)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := r.watchTree(watcher, dir, event.Name); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			rel, err := filepath.Rel(dir, event.Name)
+			if err != nil || !r.shouldProcess(filepath.ToSlash(rel), r.processTests) {
+				continue
+			}
+			if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				r.forgetFile(event.Name)
+				delete(pending, event.Name)
+				continue
+			}
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			pending[event.Name] = true
+			timer.Reset(watchDebounce)
+		case <-timer.C:
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			pending = make(map[string]bool)
+
+			for _, path := range paths {
+				if r.matchesBuild(path) && !r.isGenerated(path) {
+					r.watchFile(path)
+				}
+			}
+		}
+	}
+}
+
+// watchTree adds root and every directory under it that isn't excluded
+// to watcher; dir is the directory being watched
+func (r *SwaggerVariableReplacer) watchTree(watcher *fsnotify.Watcher, dir, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if r.skipDir(filepath.ToSlash(rel)) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// fileStamp identifies the version of a file the watch cache last saw
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// stamp records the current version of the file at path in the watch cache
+func (r *SwaggerVariableReplacer) stamp(path string) {
+	if info, err := os.Stat(path); err == nil {
+		r.stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+}
+
+// forgetFile drops the constants extracted from path, falling back to
+// another file's definition where there is one, and its watch cache entry
+func (r *SwaggerVariableReplacer) forgetFile(path string) {
+	delete(r.fileConstants, path)
+	delete(r.imports, path)
+	delete(r.stamps, path)
+
+	files := make([]string, 0, len(r.fileConstants))
+	for file := range r.fileConstants {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for name, info := range r.constants {
+		if info.File != path {
+			continue
+		}
+		delete(r.constants, name)
+		for _, file := range files {
+			if other, exists := r.fileConstants[file][name]; exists {
+				r.constants[name] = other
+				break
+			}
+		}
+	}
+}
+
+// watchFile re-extracts and re-processes a file changed while watching,
+// reporting the outcome in a single line. Files whose modification time and
+// size match the watch cache, such as those just written by the replacer
+// itself, aren't parsed again. Errors don't stop the watch.
+func (r *SwaggerVariableReplacer) watchFile(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return // removed or renamed before the debounce fired
+	}
+	if stamp, cached := r.stamps[path]; cached && stamp == (fileStamp{modTime: info.ModTime(), size: info.Size()}) {
+		return
+	}
+
+	// Constants removed from the file must not outlive it
+	r.forgetFile(path)
+	res, err := r.ProcessFile(path)
+	r.stamp(path)
+	switch {
+	case err != nil && res == nil:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Processed %s: %d line(s) changed (%v)\n", path, res.LinesChanged, err)
+	default:
+		fmt.Fprintf(os.Stderr, "Processed %s: %d line(s) changed\n", path, res.LinesChanged)
+	}
+}
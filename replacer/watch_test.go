@@ -0,0 +1,148 @@
+package replacer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds, running retry between polls, and
+// fails the test after a few seconds
+func waitFor(t *testing.T, what string, retry func(), cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		retry()
+		time.Sleep(2 * watchDebounce)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "a.go", "package api\n\nconst A = 1\n\n// {{A}}\n")
+	testSrc := "package api\n\n// {{A}}\n"
+	testFile := writeTestFile(t, dir, "a_test.go", testSrc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- NewSwaggerVariableReplacer().Watch(ctx, dir) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}()
+
+	waitFor(t, "the initial run", func() {}, func() bool {
+		return strings.HasSuffix(readTestFile(t, path), "// 1\n")
+	})
+
+	// The watcher may not be set up yet, so the change is saved until seen
+	modified := "package api\n\nconst A = 2\nconst B = \"b\"\n\n// {{A}} {{B}}\n"
+	waitFor(t, "the change", func() {
+		writeTestFile(t, dir, "a.go", modified)
+		writeTestFile(t, dir, "a_test.go", testSrc)
+	}, func() bool {
+		return strings.HasSuffix(readTestFile(t, path), "// 2 b\n")
+	})
+	if got := readTestFile(t, testFile); got != testSrc {
+		t.Errorf("test file processed: %q", got)
+	}
+}
+
+func TestWatchCacheInvalidation(t *testing.T) {
+	dir := t.TempDir()
+	consts := writeTestFile(t, dir, "consts.go", "package api\n\nconst A = 1\n")
+	b := writeTestFile(t, dir, "b.go", "package api\n\n// {{A}}\n")
+
+	r := NewSwaggerVariableReplacer()
+	if err := r.ExtractFromDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	r.stamps = make(map[string]fileStamp)
+	r.stamp(consts)
+	info, err := os.Stat(consts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Same size and modification time: the cached constants are kept
+	writeTestFile(t, dir, "consts.go", "package api\n\nconst A = 2\n")
+	if err := os.Chtimes(consts, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	stderr := capture(t, &os.Stderr, func() { r.watchFile(consts) })
+	if stderr != "" || r.constants["A"].Value != 1 {
+		t.Errorf("unchanged stamp re-processed: A = %v, %q", r.constants["A"].Value, stderr)
+	}
+
+	// A new modification time invalidates the entry
+	later := info.ModTime().Add(time.Second)
+	if err := os.Chtimes(consts, later, later); err != nil {
+		t.Fatal(err)
+	}
+	capture(t, &os.Stderr, func() { r.watchFile(consts) })
+	if r.constants["A"].Value != 2 {
+		t.Errorf("A = %v after the change, want 2", r.constants["A"].Value)
+	}
+	if _, cached := r.stamps[consts]; !cached {
+		t.Error("changed file not cached again")
+	}
+
+	// Deleting the file forgets its constants
+	r.forgetFile(consts)
+	if _, exists := r.constants["A"]; exists {
+		t.Error("constant of a deleted file kept")
+	}
+	if _, cached := r.stamps[consts]; cached {
+		t.Error("deleted file still cached")
+	}
+	var res *Result
+	capture(t, &os.Stderr, func() { res, err = r.ReplaceInFile(b) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Unresolved) != 1 {
+		t.Errorf("Unresolved = %v, want [A]", res.Unresolved)
+	}
+}
+
+func BenchmarkWatchFile(b *testing.B) {
+	for _, cached := range []bool{false, true} {
+		name := "uncached"
+		if cached {
+			name = "cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			dir := b.TempDir()
+			writePackage(b, dir, 50)
+			path := filepath.Join(dir, "f000.go")
+			r := NewSwaggerVariableReplacer()
+			if err := r.ExtractFromDir(dir); err != nil {
+				b.Fatal(err)
+			}
+			r.stamps = make(map[string]fileStamp)
+			null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer null.Close()
+			stderr := os.Stderr
+			os.Stderr = null
+			defer func() { os.Stderr = stderr }()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if !cached {
+					delete(r.stamps, path)
+				}
+				r.watchFile(path)
+			}
+		})
+	}
+}